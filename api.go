@@ -4,60 +4,267 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
+	"net/http/httptrace"
+	"time"
 )
 
+// Endpoint paths, relative to the base URL.
+const (
+	EndpointCreateTask    = "/createTask"
+	EndpointGetTaskResult = "/getTaskResult"
+	EndpointGetBalance    = "/getBalance"
+)
+
+// responseEnvelope holds the in-band error fields shared by all API responses.
+type responseEnvelope struct {
+	ErrorCode        int        `json:"error_code"`
+	ErrorDescription string     `json:"error_description"`
+	ErrorId          int        `json:"errorId"`
+	Status           TaskStatus `json:"status"`
+	TaskId           string     `json:"taskId"`
+}
+
 func (c *client) postJSON(ctx context.Context, path string, requestBody any, responseDest any) error {
-	url := fmt.Sprintf("%s%s", c.baseURL, path)
+	if c.timeout > 0 {
+		if _, ok := ctx.Deadline(); !ok {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, c.timeout)
+			defer cancel()
+		}
+	}
+
+	policy := c.retryPolicyFor(path)
+	taskType := taskTypeFromContext(ctx)
+	// sent records whether an earlier attempt may have been acted on.
+	sent := false
+	for attempt := 1; ; attempt++ {
+		call := &Call{
+			Endpoint: path,
+			Request:  requestBody,
+			TaskType: taskType,
+			Attempt:  attempt,
+		}
+		release, err := c.limiters.acquire(ctx, path, taskType)
+		if err != nil {
+			c.logCall(ctx, call, callInfo{}, err, false, 0)
+			if !sent {
+				return &notSentError{err: err}
+			}
+			return err
+		}
+		if path == EndpointCreateTask {
+			taskClockFromContext(ctx).sending()
+		}
+		attemptCtx, span := c.startSpan(ctx, SpanAttempt,
+			slog.String(AttrEndpoint, path),
+			slog.Int(AttrAttempt, attempt),
+		)
+		info, err := c.do(attemptCtx, call, responseDest)
+		if info.httpStatus != 0 {
+			span.SetAttributes(slog.Int(AttrHTTPStatus, info.httpStatus))
+		}
+		span.End(err)
+		release()
+		if err == nil {
+			c.logCall(ctx, call, info, nil, false, 0)
+			return nil
+		}
+
+		sent = sent || !isUnprocessed(err)
+
+		delay, ok := policy.next(ctx, path, attempt, err)
+		if !ok {
+			c.logCall(ctx, call, info, err, false, 0)
+			return err
+		}
+		c.logCall(ctx, call, info, err, true, delay)
+		if policy.OnRetry != nil {
+			policy.OnRetry(RetryEvent{
+				Endpoint: path,
+				Attempt:  attempt,
+				Delay:    delay,
+				Err:      err,
+			})
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// notSentError wraps the error of a call that gave up before any attempt
+// could have reached the server, e.g. while waiting for a client-side limit.
+type notSentError struct {
+	err error
+}
+
+func (e *notSentError) Error() string { return e.err.Error() }
+func (e *notSentError) Unwrap() error { return e.err }
+
+// do performs a single attempt of an API call through the middleware chain
+// and decodes the response body into responseDest.
+func (c *client) do(ctx context.Context, call *Call, responseDest any) (info callInfo, err error) {
+	if req, ok := call.Request.(TaskRequest); ok {
+		info.taskId = req.TaskId
+	}
+	start := time.Now()
+	defer func() {
+		info.duration = time.Since(start)
+		c.usage.latency(call, info.duration)
+
+		var apiErr *APIError
+		if errors.As(err, &apiErr) {
+			info.httpStatus = apiErr.StatusCode
+			if info.taskId == "" {
+				info.taskId = apiErr.TaskId
+			}
+		}
+	}()
+
+	resp, err := c.handler(ctx, call)
+	if err != nil {
+		return info, err
+	}
+	if resp == nil {
+		return info, fmt.Errorf("decode response: no response from handler")
+	}
+	info.httpStatus = resp.StatusCode
+	info.timings = resp.Timings
+	if c.logger != nil {
+		var env responseEnvelope
+		if json.Unmarshal(resp.Body, &env) == nil {
+			info.status = env.Status
+			if info.taskId == "" {
+				info.taskId = env.TaskId
+			}
+		}
+	}
+
+	if err := json.Unmarshal(resp.Body, responseDest); err != nil {
+		return info, fmt.Errorf("decode response: %w", err)
+	}
+	if strict, ok := responseDest.(interface{ checkUnknownFields() error }); ok && c.strictDecoding {
+		if err := strict.checkUnknownFields(); err != nil {
+			return info, fmt.Errorf("decode response: %w", err)
+		}
+	}
+	if timed, ok := responseDest.(httpTimed); ok && resp.Timings != nil {
+		timed.setHTTPTimings(resp.Timings)
+	}
+
+	return info, nil
+}
 
-	payload, err := json.Marshal(requestBody)
+// send is the innermost Handler. It performs the HTTP exchange and turns
+// error responses into errors.
+func (c *client) send(ctx context.Context, call *Call) (*Response, error) {
+	payload, err := json.Marshal(call.Request)
 	if err != nil {
-		return fmt.Errorf("marshal request: %w", err)
+		return nil, fmt.Errorf("marshal request: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(payload))
+	url := fmt.Sprintf("%s%s", c.baseURL, call.Endpoint)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
 	if err != nil {
-		return fmt.Errorf("new request: %w", err)
+		return nil, fmt.Errorf("new request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
+
+	var timer *httpTimer
+	if c.httpTrace {
+		timer = newHTTPTimer()
+		req = req.WithContext(httptrace.WithClientTrace(ctx, timer.trace()))
+	}
 
+	start := time.Now()
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("http do: %w", err)
+		return nil, fmt.Errorf("http do: %w", err)
 	}
 	defer resp.Body.Close()
 
+	bodyStart := time.Now()
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return fmt.Errorf("read response: %w", err)
+		return nil, fmt.Errorf("read response: %w", err)
 	}
 
-	if resp.StatusCode == http.StatusBadRequest {
+	result := &Response{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       body,
+		Latency:    time.Since(start),
+	}
+	if timer != nil {
+		result.Timings = timer.finish(bodyStart)
+	}
+
+	if resp.StatusCode != http.StatusOK {
 		var apiErr struct {
 			ErrorCode        int    `json:"error_code"`
 			ErrorDescription string `json:"error_description"`
 		}
+		msg := string(body)
 		if jsonErr := json.Unmarshal(body, &apiErr); jsonErr == nil && apiErr.ErrorDescription != "" {
-			return &APIError{
-				StatusCode: http.StatusBadRequest,
-				Msg:        apiErr.ErrorDescription,
-			}
-		}
-		return &APIError{
-			StatusCode: http.StatusBadRequest,
-			Msg:        string(body),
+			msg = apiErr.ErrorDescription
 		}
+		err := newAPIError(call, result, msg, apiErr.ErrorCode)
+		err.RetryAfter = parseRetryAfter(resp.Header.Get("Retry-After"))
+		return result, err
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(body))
+	var envelope responseEnvelope
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return result, fmt.Errorf("decode response: %w", err)
 	}
-
-	if err := json.Unmarshal(body, responseDest); err != nil {
-		return fmt.Errorf("decode response: %w", err)
+	if envelope.ErrorCode != 0 {
+		err := newAPIError(call, result, envelope.ErrorDescription, envelope.ErrorCode)
+		err.TaskId = envelope.TaskId
+		return result, err
 	}
+	if envelope.ErrorId != 0 {
+		msg := envelope.ErrorDescription
+		if msg == "" {
+			msg = fmt.Sprintf("task status %q", envelope.Status)
+		}
+		err := newAPIError(call, result, msg, envelope.ErrorId)
+		err.TaskId = envelope.TaskId
+		err.Status = envelope.Status
+		if req, ok := call.Request.(TaskRequest); ok {
+			err.TaskId = req.TaskId
+		}
+		return result, err
+	}
+
+	return result, nil
+}
 
-	return nil
+const maxErrorBodyLen = 1024
+
+func newAPIError(call *Call, resp *Response, msg string, errorCode int) *APIError {
+	body := resp.Body
+	if len(body) > maxErrorBodyLen {
+		body = body[:maxErrorBodyLen]
+	}
+	return &APIError{
+		StatusCode: resp.StatusCode,
+		Msg:        msg,
+		ErrorCode:  errorCode,
+		Endpoint:   call.Endpoint,
+		Body:       string(body),
+		Header:     resp.Header,
+	}
 }
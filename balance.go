@@ -0,0 +1,360 @@
+package salamoonder
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// balanceDecimals is the precision of Balance, well beyond the 5 decimals
+// the API reports.
+const balanceDecimals = 8
+
+const balanceScale = 100_000_000
+
+// maxBalance and minBalance are where arithmetic saturates. The range is
+// symmetric so that every Balance can be negated and printed.
+var (
+	maxBalance = Balance{units: math.MaxInt64}
+	minBalance = Balance{units: -math.MaxInt64}
+)
+
+const defaultMonitorInterval = time.Minute
+
+type (
+	// Balance is an exact amount of wallet credit. The zero value is 0.
+	// Balances are comparable with ==.
+	Balance struct {
+		units int64 // 1e-8 of a credit
+	}
+
+	// BalanceEventKind says what a BalanceEvent reports.
+	BalanceEventKind int
+
+	// BalanceEvent is emitted by a BalanceMonitor.
+	BalanceEvent struct {
+		Kind BalanceEventKind
+		// Threshold is the crossed threshold for BalanceBelow and
+		// BalanceAbove.
+		Threshold Balance
+		Balance   Balance
+		// Previous is the balance of the last successful check, if any.
+		Previous Balance
+		// Err is set for BalanceCheckFailed.
+		Err error
+	}
+
+	// BalanceMonitorOption configures a BalanceMonitor.
+	BalanceMonitorOption func(*BalanceMonitor)
+
+	// BalanceMonitor polls the wallet and reports threshold crossings.
+	BalanceMonitor struct {
+		client     *Client
+		interval   time.Duration
+		thresholds []Balance
+		handler    func(BalanceEvent)
+
+		mu      sync.Mutex
+		last    Balance
+		checked time.Time
+		known   bool
+	}
+)
+
+const (
+	// BalanceBelow: the balance dropped to or below a threshold, or was
+	// already there at the first check.
+	BalanceBelow BalanceEventKind = iota + 1
+	// BalanceAbove: the balance rose above a threshold, e.g. after a top-up.
+	BalanceAbove
+	// BalanceCheckFailed: polling the balance failed.
+	BalanceCheckFailed
+)
+
+// ParseBalance parses a decimal amount such as "499.81070" or "-0.5".
+// More than 8 decimal places is an error rather than being rounded.
+func ParseBalance(s string) (Balance, error) {
+	str := strings.TrimSpace(s)
+	neg := false
+	switch {
+	case strings.HasPrefix(str, "-"):
+		neg, str = true, str[1:]
+	case strings.HasPrefix(str, "+"):
+		str = str[1:]
+	}
+
+	whole, frac, hasDot := strings.Cut(str, ".")
+	if whole == "" && (!hasDot || frac == "") || len(frac) > balanceDecimals || !isDigits(whole) || !isDigits(frac) {
+		return Balance{}, fmt.Errorf("invalid balance %q", s)
+	}
+	w := int64(0)
+	if whole != "" {
+		var err error
+		if w, err = strconv.ParseInt(whole, 10, 64); err != nil || w > math.MaxInt64/balanceScale-1 {
+			return Balance{}, fmt.Errorf("invalid balance %q: out of range", s)
+		}
+	}
+	f := int64(0)
+	if frac != "" {
+		f, _ = strconv.ParseInt(frac+strings.Repeat("0", balanceDecimals-len(frac)), 10, 64)
+	}
+
+	units := w*balanceScale + f
+	if neg {
+		units = -units
+	}
+	return Balance{units: units}, nil
+}
+
+// MustParseBalance is like ParseBalance but panics on invalid input. It is
+// meant for constants such as thresholds and prices.
+func MustParseBalance(s string) Balance {
+	b, err := ParseBalance(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// Amount parses Wallet.
+func (r *CreateTaskBalanceResult) Amount() (Balance, error) {
+	return ParseBalance(r.Wallet)
+}
+
+// Add returns b plus o. Like Mul, it saturates instead of wrapping around.
+func (b Balance) Add(o Balance) Balance {
+	sum := b.units + o.units
+	switch {
+	case b.units > 0 && o.units > 0 && sum < 0:
+		return maxBalance
+	case b.units < 0 && o.units < 0 && (sum >= 0 || sum == math.MinInt64):
+		return minBalance
+	}
+	return Balance{units: sum}
+}
+
+// Sub returns b minus o, saturating like Add.
+func (b Balance) Sub(o Balance) Balance {
+	return b.Add(Balance{units: -max(o.units, -math.MaxInt64)})
+}
+
+// Mul returns b times n, e.g. the price of n tasks. A product beyond the
+// range of Balance, about ±92 billion credits, saturates at the largest or
+// smallest Balance instead of wrapping around.
+func (b Balance) Mul(n int64) Balance {
+	if b.units == 0 || n == 0 {
+		return Balance{}
+	}
+	p := b.units * n
+	if p/n != b.units || (n == -1 && b.units == math.MinInt64) {
+		if (b.units < 0) == (n < 0) {
+			return maxBalance
+		}
+		return minBalance
+	}
+	if p == math.MinInt64 {
+		return minBalance
+	}
+	return Balance{units: p}
+}
+
+// Cmp returns -1, 0 or +1 as b is less than, equal to or greater than o.
+func (b Balance) Cmp(o Balance) int {
+	switch {
+	case b.units < o.units:
+		return -1
+	case b.units > o.units:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func (b Balance) LessThan(o Balance) bool {
+	return b.units < o.units
+}
+
+func (b Balance) GreaterThan(o Balance) bool {
+	return b.units > o.units
+}
+
+func (b Balance) IsZero() bool {
+	return b.units == 0
+}
+
+// Sign returns -1, 0 or +1 depending on the sign of b.
+func (b Balance) Sign() int {
+	return b.Cmp(Balance{})
+}
+
+// Float64 returns b as a float, for display and metrics only.
+func (b Balance) Float64() float64 {
+	return float64(b.units) / balanceScale
+}
+
+// String formats b with at least 2 and at most 8 decimal places.
+func (b Balance) String() string {
+	units := b.units
+	sign := ""
+	if units < 0 {
+		sign = "-"
+		units = -units
+	}
+	frac := strings.TrimRight(fmt.Sprintf("%08d", units%balanceScale), "0")
+	for len(frac) < 2 {
+		frac += "0"
+	}
+	return fmt.Sprintf("%s%d.%s", sign, units/balanceScale, frac)
+}
+
+// MarshalJSON encodes b as a JSON string, like the API's wallet field.
+func (b Balance) MarshalJSON() ([]byte, error) {
+	return json.Marshal(b.String())
+}
+
+// UnmarshalJSON accepts a JSON string or number. null leaves b unchanged.
+func (b *Balance) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	v, err := ParseBalance(string(bytes.Trim(data, `"`)))
+	if err != nil {
+		return err
+	}
+	*b = v
+	return nil
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// WithMonitorInterval sets how often the balance is polled. The default is
+// one minute.
+func WithMonitorInterval(d time.Duration) BalanceMonitorOption {
+	return func(m *BalanceMonitor) {
+		m.interval = d
+	}
+}
+
+// WithThresholds sets the balances whose crossing emits an event.
+func WithThresholds(thresholds ...Balance) BalanceMonitorOption {
+	return func(m *BalanceMonitor) {
+		m.thresholds = append(m.thresholds, thresholds...)
+	}
+}
+
+// WithBalanceHandler sets the function events are delivered to. It is
+// called from the monitor's goroutine.
+func WithBalanceHandler(fn func(BalanceEvent)) BalanceMonitorOption {
+	return func(m *BalanceMonitor) {
+		m.handler = fn
+	}
+}
+
+// NewBalanceMonitor creates a monitor for c's wallet. Start it with Run.
+func NewBalanceMonitor(c *Client, opts ...BalanceMonitorOption) *BalanceMonitor {
+	m := &BalanceMonitor{
+		client:   c,
+		interval: defaultMonitorInterval,
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(m)
+		}
+	}
+	if m.interval <= 0 {
+		m.interval = defaultMonitorInterval
+	}
+	return m
+}
+
+// Run checks the balance immediately and then on every interval until ctx
+// is done, which it returns.
+func (m *BalanceMonitor) Run(ctx context.Context) error {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		m.Check(ctx)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check polls the balance once, emits any events and returns the balance.
+func (m *BalanceMonitor) Check(ctx context.Context) (Balance, error) {
+	result, err := m.client.Balance(ctx)
+	var b Balance
+	if err == nil {
+		b, err = result.Amount()
+	}
+	if err != nil {
+		if ctx.Err() == nil {
+			m.emit(BalanceEvent{Kind: BalanceCheckFailed, Previous: m.lastBalance(), Err: err})
+		}
+		return Balance{}, err
+	}
+
+	m.mu.Lock()
+	prev, known := m.last, m.known
+	m.last, m.known, m.checked = b, true, time.Now()
+	m.mu.Unlock()
+
+	for _, t := range m.thresholds {
+		switch {
+		case !b.GreaterThan(t) && (!known || prev.GreaterThan(t)):
+			m.emit(BalanceEvent{Kind: BalanceBelow, Threshold: t, Balance: b, Previous: prev})
+		case known && b.GreaterThan(t) && !prev.GreaterThan(t):
+			m.emit(BalanceEvent{Kind: BalanceAbove, Threshold: t, Balance: b, Previous: prev})
+		}
+	}
+	return b, nil
+}
+
+// Last returns the balance of the last successful check and when it was
+// made. ok is false before the first successful check.
+func (m *BalanceMonitor) Last() (b Balance, at time.Time, ok bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.last, m.checked, m.known
+}
+
+func (m *BalanceMonitor) lastBalance() Balance {
+	b, _, _ := m.Last()
+	return b
+}
+
+func (m *BalanceMonitor) emit(e BalanceEvent) {
+	if m.handler != nil {
+		m.handler(e)
+	}
+}
+
+func (k BalanceEventKind) String() string {
+	switch k {
+	case BalanceBelow:
+		return "below"
+	case BalanceAbove:
+		return "above"
+	case BalanceCheckFailed:
+		return "check failed"
+	default:
+		return fmt.Sprintf("BalanceEventKind(%d)", int(k))
+	}
+}
@@ -0,0 +1,232 @@
+package salamoonder
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// ------------------------------------------------------------------
+// Balance
+// ------------------------------------------------------------------
+
+func TestParseBalance(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"499.81070", "499.8107"},
+		{"0", "0.00"},
+		{"0.1", "0.10"},
+		{".5", "0.50"},
+		{"-1.25", "-1.25"},
+		{"+3", "3.00"},
+		{"0.00000001", "0.00000001"},
+		{" 12.3 ", "12.30"},
+	}
+	for _, tt := range tests {
+		b, err := ParseBalance(tt.in)
+		if err != nil {
+			t.Errorf("ParseBalance(%q) error: %v", tt.in, err)
+			continue
+		}
+		if got := b.String(); got != tt.want {
+			t.Errorf("ParseBalance(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+
+	for _, in := range []string{"", ".", "-", "abc", "1.2.3", "1e5", "0.000000001", "99999999999999"} {
+		if _, err := ParseBalance(in); err == nil {
+			t.Errorf("ParseBalance(%q) error = nil, want error", in)
+		}
+	}
+}
+
+func TestBalance_Arithmetic(t *testing.T) {
+	// 0.1 + 0.2 is exactly 0.3, unlike with float64.
+	sum := MustParseBalance("0.1").Add(MustParseBalance("0.2"))
+	if sum != MustParseBalance("0.3") {
+		t.Errorf("0.1 + 0.2 = %s, want 0.30", sum)
+	}
+
+	wallet := MustParseBalance("1.00000")
+	price := MustParseBalance("0.0015")
+	left := wallet.Sub(price.Mul(600))
+	if left.String() != "0.10" {
+		t.Errorf("1 - 600*0.0015 = %s, want 0.10", left)
+	}
+	if !left.LessThan(wallet) || !wallet.GreaterThan(left) || left.Cmp(left) != 0 {
+		t.Error("comparison helpers disagree")
+	}
+	if wallet.Sub(wallet.Mul(2)).Sign() != -1 || !(Balance{}).IsZero() {
+		t.Error("Sign/IsZero disagree")
+	}
+}
+
+func TestBalance_Saturates(t *testing.T) {
+	hi, lo := Balance{units: math.MaxInt64}, Balance{units: -math.MaxInt64}
+	price := MustParseBalance("0.5")
+	big := MustParseBalance("90000000000")
+	tests := []struct {
+		name string
+		got  Balance
+		want Balance
+	}{
+		{"mul positive overflow", price.Mul(math.MaxInt64), hi},
+		{"mul negative overflow", price.Mul(math.MinInt64), lo},
+		{"mul negative times negative", price.Sub(MustParseBalance("1")).Mul(math.MinInt64), hi},
+		{"mul lo times -1", lo.Mul(-1), hi},
+		{"mul in range", price.Mul(-4), MustParseBalance("-2")},
+		{"add positive overflow", big.Add(big), hi},
+		{"add negative overflow", lo.Add(MustParseBalance("-0.00000001")), lo},
+		{"add in range", big.Add(big.Mul(-1)), Balance{}},
+		{"sub positive overflow", big.Sub(big.Mul(-1)), hi},
+		{"sub negative overflow", big.Mul(-1).Sub(big), lo},
+		{"sub in range", lo.Sub(lo), Balance{}},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.name, tt.got, tt.want)
+		}
+	}
+
+	if got := MustParseBalance("-1").Mul(math.MaxInt64).String(); got != "-92233720368.54775807" {
+		t.Errorf("String() of saturated product = %q, want -92233720368.54775807", got)
+	}
+}
+
+func TestBalance_JSON(t *testing.T) {
+	var v struct {
+		A Balance `json:"a"`
+		B Balance `json:"b"`
+	}
+	if err := json.Unmarshal([]byte(`{"a":"499.81070","b":1.5}`), &v); err != nil {
+		t.Fatalf("Unmarshal() error: %v", err)
+	}
+	if v.A.String() != "499.8107" || v.B.String() != "1.50" {
+		t.Errorf("decoded = %s, %s", v.A, v.B)
+	}
+	data, _ := json.Marshal(v)
+	if string(data) != `{"a":"499.8107","b":"1.50"}` {
+		t.Errorf("Marshal() = %s", data)
+	}
+}
+
+// ------------------------------------------------------------------
+// BalanceMonitor
+// ------------------------------------------------------------------
+
+func walletHandler(wallets ...string) (http.HandlerFunc, *atomic.Int32) {
+	var calls atomic.Int32
+	return func(w http.ResponseWriter, r *http.Request) {
+		i := int(calls.Add(1)) - 1
+		if i >= len(wallets) {
+			i = len(wallets) - 1
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if wallets[i] == "error" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error_code":1,"error_description":"invalid api key"}`))
+			return
+		}
+		fmt.Fprintf(w, `{"error_code":0,"wallet":%q}`, wallets[i])
+	}, &calls
+}
+
+func TestBalanceMonitor_Thresholds(t *testing.T) {
+	h, _ := walletHandler("20.00000", "9.50000", "4.00000", "error", "15.00000")
+	c, closeFn := newTestClient(t, h)
+	defer closeFn()
+
+	var events []BalanceEvent
+	m := NewBalanceMonitor(c,
+		WithThresholds(MustParseBalance("10"), MustParseBalance("5")),
+		WithBalanceHandler(func(e BalanceEvent) { events = append(events, e) }),
+	)
+
+	if _, _, ok := m.Last(); ok {
+		t.Error("Last() ok = true before first check")
+	}
+	for range 5 {
+		m.Check(context.Background())
+	}
+
+	want := []struct {
+		kind      BalanceEventKind
+		threshold string
+	}{
+		{BalanceBelow, "10.00"},
+		{BalanceBelow, "5.00"},
+		{BalanceCheckFailed, "0.00"},
+		{BalanceAbove, "10.00"},
+		{BalanceAbove, "5.00"},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("events = %+v, want %d", events, len(want))
+	}
+	for i, w := range want {
+		if events[i].Kind != w.kind || events[i].Threshold.String() != w.threshold {
+			t.Errorf("events[%d] = %s %s, want %s %s", i, events[i].Kind, events[i].Threshold, w.kind, w.threshold)
+		}
+	}
+	if events[2].Err == nil {
+		t.Error("BalanceCheckFailed event without Err")
+	}
+
+	b, at, ok := m.Last()
+	if !ok || b.String() != "15.00" || at.IsZero() {
+		t.Errorf("Last() = %s, %v, %v; want 15.00", b, at, ok)
+	}
+}
+
+func TestBalanceMonitor_BelowAtStart(t *testing.T) {
+	h, _ := walletHandler("1.00000")
+	c, closeFn := newTestClient(t, h)
+	defer closeFn()
+
+	var events []BalanceEvent
+	m := NewBalanceMonitor(c,
+		WithThresholds(MustParseBalance("5")),
+		WithBalanceHandler(func(e BalanceEvent) { events = append(events, e) }),
+	)
+	m.Check(context.Background())
+	m.Check(context.Background())
+
+	if len(events) != 1 || events[0].Kind != BalanceBelow {
+		t.Errorf("events = %+v, want one BalanceBelow", events)
+	}
+}
+
+func TestBalanceMonitor_Run(t *testing.T) {
+	h, calls := walletHandler("3.00000")
+	c, closeFn := newTestClient(t, h)
+	defer closeFn()
+
+	var mu sync.Mutex
+	var events int
+	m := NewBalanceMonitor(c,
+		WithMonitorInterval(time.Millisecond),
+		WithThresholds(MustParseBalance("5")),
+		WithBalanceHandler(func(BalanceEvent) { mu.Lock(); events++; mu.Unlock() }),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	if err := m.Run(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Run() = %v, want context.DeadlineExceeded", err)
+	}
+	if n := calls.Load(); n < 2 {
+		t.Errorf("balance calls = %d, want several", n)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if events != 1 {
+		t.Errorf("events = %d, want 1", events)
+	}
+}
@@ -0,0 +1,172 @@
+package salamoonder
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// defaultBatchConcurrency is the number of calls a batch runs at once unless
+// WithBatchConcurrency is used. Client-side limits apply on top of it.
+const defaultBatchConcurrency = 10
+
+var _ error = (*BatchError)(nil)
+
+type (
+	// BatchItem is the outcome of one element of a batch. Exactly one of
+	// Result and Err is set.
+	BatchItem[T any] struct {
+		Index  int
+		Result *T
+		Err    error
+	}
+
+	// BatchProgress is reported after every finished element of a batch.
+	BatchProgress struct {
+		// Index is the position of the element that just finished.
+		Index int
+		Err   error
+		// Done and Failed count finished and failed elements so far.
+		Done   int
+		Failed int
+		Total  int
+	}
+
+	// BatchOption configures CreateTasks, TaskResults and GetTaskResults.
+	BatchOption func(*batchConfig)
+
+	batchConfig struct {
+		concurrency int
+		progress    func(BatchProgress)
+	}
+
+	// BatchError is returned alongside the items of a batch when some of
+	// them failed. errors.Is and errors.As see the error of every failed item.
+	BatchError struct {
+		Failed int
+		Total  int
+		// Errs holds the error of every failed item in input order.
+		Errs []error
+	}
+)
+
+// WithBatchConcurrency sets how many calls of a batch run at once. The
+// default is 10.
+func WithBatchConcurrency(n int) BatchOption {
+	return func(cfg *batchConfig) {
+		cfg.concurrency = n
+	}
+}
+
+// WithProgress calls fn after every finished element. Calls are serialized,
+// so fn needs no locking of its own.
+func WithProgress(fn func(BatchProgress)) BatchOption {
+	return func(cfg *batchConfig) {
+		cfg.progress = fn
+	}
+}
+
+// CreateTasks creates a task for every element of options, which may mix
+// task types. Items are returned in input order; a failing element does not
+// stop the others, and the error is a *BatchError if any element failed.
+func (c *Client) CreateTasks(ctx context.Context, options []any, opts ...BatchOption) ([]BatchItem[CreateTaskResult], error) {
+	return runBatch(ctx, len(options), opts, func(ctx context.Context, i int) (*CreateTaskResult, error) {
+		return c.CreateTask(ctx, options[i])
+	})
+}
+
+// TaskResults fetches the current result of every task id, like Task. It
+// follows the partial-failure semantics of CreateTasks.
+func (c *Client) TaskResults(ctx context.Context, taskIds []string, opts ...BatchOption) ([]BatchItem[TaskResultRaw], error) {
+	return runBatch(ctx, len(taskIds), opts, func(ctx context.Context, i int) (*TaskResultRaw, error) {
+		return c.Task(ctx, taskIds[i])
+	})
+}
+
+// GetTaskResults is the typed variant of TaskResults for tasks that share a
+// solution type.
+func GetTaskResults[TS TaskSolution](c *Client, ctx context.Context, taskIds []string, opts ...BatchOption) ([]BatchItem[TaskResult[TS]], error) {
+	return runBatch(ctx, len(taskIds), opts, func(ctx context.Context, i int) (*TaskResult[TS], error) {
+		return GetTaskResult[TS](c, ctx, taskIds[i])
+	})
+}
+
+func runBatch[T any](ctx context.Context, n int, opts []BatchOption, call func(context.Context, int) (*T, error)) ([]BatchItem[T], error) {
+	cfg := batchConfig{concurrency: defaultBatchConcurrency}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&cfg)
+		}
+	}
+	if cfg.concurrency <= 0 {
+		cfg.concurrency = defaultBatchConcurrency
+	}
+
+	items := make([]BatchItem[T], n)
+	var (
+		mu       sync.Mutex
+		progress = BatchProgress{Total: n}
+		wg       sync.WaitGroup
+		sem      = make(chan struct{}, cfg.concurrency)
+	)
+	finish := func(i int, result *T, err error) {
+		items[i] = BatchItem[T]{Index: i, Result: result, Err: err}
+
+		mu.Lock()
+		defer mu.Unlock()
+		progress.Index = i
+		progress.Err = err
+		progress.Done++
+		if err != nil {
+			progress.Failed++
+		}
+		if cfg.progress != nil {
+			cfg.progress(progress)
+		}
+	}
+
+	for i := 0; i < n; i++ {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			finish(i, nil, ctx.Err())
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			result, err := call(ctx, i)
+			if err != nil {
+				result = nil
+			}
+			finish(i, result, err)
+		}()
+	}
+	wg.Wait()
+
+	var errs []error
+	for _, item := range items {
+		if item.Err != nil {
+			errs = append(errs, item.Err)
+		}
+	}
+	if len(errs) > 0 {
+		return items, &BatchError{Failed: len(errs), Total: n, Errs: errs}
+	}
+	return items, nil
+}
+
+func (b *BatchError) Error() string {
+	return fmt.Sprintf("batch: %d of %d failed; first error: %v", b.Failed, b.Total, b.Errs[0])
+}
+
+/*
+Unwrap allows using errors.Is and errors.As on the errors of the failed
+items, e.g. errors.Is(err, ErrInsufficientBalance).
+*/
+func (b *BatchError) Unwrap() []error {
+	return b.Errs
+}
@@ -0,0 +1,189 @@
+package salamoonder
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// ------------------------------------------------------------------
+// CreateTasks
+// ------------------------------------------------------------------
+
+func TestCreateTasks_PartialFailure(t *testing.T) {
+	var inFlight, maxInFlight atomic.Int32
+	h := func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			m := maxInFlight.Load()
+			if n <= m || maxInFlight.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+
+		var req struct {
+			Task struct {
+				Pjs string `json:"pjs"`
+			} `json:"task"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		w.Header().Set("Content-Type", "application/json")
+		pjs := req.Task.Pjs
+		if pjs == "https://example.com/broke" {
+			w.Write([]byte(`{"error_code":1,"error_description":"insufficient funds","taskId":""}`))
+			return
+		}
+		fmt.Fprintf(w, `{"error_code":0,"taskId":"task-%s"}`, pjs[len(pjs)-1:])
+	}
+	c, closeFn := newTestClient(t, h)
+	defer closeFn()
+
+	options := []any{
+		KasadaStandardOptions{Pjs: "https://example.com/0"},
+		KasadaStandardOptions{Pjs: "https://example.com/broke"},
+		KasadaStandardOptions{},
+		KasadaStandardOptions{Pjs: "https://example.com/3"},
+		"not options",
+		KasadaStandardOptions{Pjs: "https://example.com/5"},
+	}
+
+	var progress []BatchProgress
+	items, err := c.CreateTasks(context.Background(), options,
+		WithBatchConcurrency(2),
+		WithProgress(func(p BatchProgress) { progress = append(progress, p) }),
+	)
+
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) {
+		t.Fatalf("CreateTasks() error = %v, want *BatchError", err)
+	}
+	if batchErr.Failed != 3 || batchErr.Total != 6 {
+		t.Errorf("BatchError = %d of %d failed, want 3 of 6", batchErr.Failed, batchErr.Total)
+	}
+	for _, target := range []error{ErrInsufficientBalance, ErrInvalidOptions, ErrUnsupportedTaskOptionsType} {
+		if !errors.Is(err, target) {
+			t.Errorf("errors.Is(err, %v) = false, want true", target)
+		}
+	}
+
+	if len(items) != len(options) {
+		t.Fatalf("len(items) = %d, want %d", len(items), len(options))
+	}
+	for i, want := range []string{"task-0", "", "", "task-3", "", "task-5"} {
+		item := items[i]
+		if item.Index != i {
+			t.Errorf("items[%d].Index = %d", i, item.Index)
+		}
+		if want == "" {
+			if item.Err == nil || item.Result != nil {
+				t.Errorf("items[%d] = %+v, want error only", i, item)
+			}
+			continue
+		}
+		if item.Err != nil || item.Result == nil || item.Result.TaskId != want {
+			t.Errorf("items[%d] = %+v, want task %s", i, item, want)
+		}
+	}
+
+	if len(progress) != 6 {
+		t.Fatalf("progress calls = %d, want 6", len(progress))
+	}
+	if last := progress[5]; last.Done != 6 || last.Failed != 3 || last.Total != 6 {
+		t.Errorf("last progress = %+v, want 6 done, 3 failed", last)
+	}
+	if m := maxInFlight.Load(); m > 2 {
+		t.Errorf("max in-flight calls = %d, want <= 2", m)
+	}
+}
+
+func TestCreateTasks_AllSucceed(t *testing.T) {
+	h := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"error_code":0,"taskId":"task-1"}`))
+	}
+	c, closeFn := newTestClient(t, h)
+	defer closeFn()
+
+	options := make([]any, 50)
+	for i := range options {
+		options[i] = KasadaStandardOptions{Pjs: "https://example.com/p.js"}
+	}
+	items, err := c.CreateTasks(context.Background(), options)
+	if err != nil {
+		t.Fatalf("CreateTasks() error: %v", err)
+	}
+	for i, item := range items {
+		if item.Result == nil || item.Result.TaskId != "task-1" {
+			t.Errorf("items[%d] = %+v, want task-1", i, item)
+		}
+	}
+}
+
+func TestCreateTasks_Canceled(t *testing.T) {
+	h := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"error_code":0,"taskId":"task-1"}`))
+	}
+	c, closeFn := newTestClient(t, h)
+	defer closeFn()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	items, err := c.CreateTasks(ctx, []any{KasadaStandardOptions{Pjs: "https://example.com/p.js"}})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("errors.Is(err, context.Canceled) = false, want true; err = %v", err)
+	}
+	if items[0].Err == nil {
+		t.Error("items[0].Err = nil, want context.Canceled")
+	}
+}
+
+// ------------------------------------------------------------------
+// TaskResults
+// ------------------------------------------------------------------
+
+func TestTaskResults(t *testing.T) {
+	h := func(w http.ResponseWriter, r *http.Request) {
+		var req TaskRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		w.Header().Set("Content-Type", "application/json")
+		switch req.TaskId {
+		case "missing":
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error_code":1,"error_description":"task not found"}`))
+		case "pending":
+			w.Write([]byte(`{"errorId":0,"status":"pending","solution":null}`))
+		default:
+			fmt.Fprintf(w, `{"errorId":0,"status":"ready","solution":{"user-agent":%q}}`, req.TaskId)
+		}
+	}
+	c, closeFn := newTestClient(t, h)
+	defer closeFn()
+
+	ids := []string{"a", "missing", "pending", "b"}
+
+	raw, err := c.TaskResults(context.Background(), ids)
+	if !errors.Is(err, ErrTaskNotFound) {
+		t.Errorf("errors.Is(err, ErrTaskNotFound) = false, want true; err = %v", err)
+	}
+	if raw[2].Result == nil || raw[2].Result.Status != TaskStatusPending {
+		t.Errorf("raw[2] = %+v, want pending", raw[2])
+	}
+
+	typed, _ := GetTaskResults[KasadaStandardSolution](c, context.Background(), ids)
+	for _, i := range []int{0, 3} {
+		if typed[i].Result == nil || typed[i].Result.Solution.UserAgent != ids[i] {
+			t.Errorf("typed[%d] = %+v, want solution for %s", i, typed[i], ids[i])
+		}
+	}
+	if typed[1].Err == nil {
+		t.Error("typed[1].Err = nil, want task not found")
+	}
+}
@@ -0,0 +1,313 @@
+package salamoonder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// BudgetHardStop makes task creation fail with ErrBudgetExceeded when a
+	// cap would be exceeded. It is the default.
+	BudgetHardStop BudgetMode = iota
+	// BudgetWarn lets task creation proceed and only reports the excess to
+	// Budget.OnExceeded.
+	BudgetWarn
+)
+
+// reconcileTimeout bounds the Balance call of a background reconciliation.
+const reconcileTimeout = 30 * time.Second
+
+type (
+	// Budget caps what the client may spend on tasks. Spend is counted
+	// client-side from Prices when a task is created.
+	Budget struct {
+		// Prices is the price of one task per task type name.
+		Prices map[string]Balance
+		// DefaultPrice applies to task types missing from Prices.
+		DefaultPrice Balance
+		// Windows are rolling spending caps, e.g. per minute and per day.
+		Windows []BudgetWindow
+		// Total caps the spend over the client's lifetime. Zero is unlimited.
+		Total Balance
+		Mode  BudgetMode
+		// OnExceeded, if set, is called whenever a task would exceed a cap,
+		// in both modes.
+		OnExceeded func(BudgetEvent)
+		// ReconcileInterval, if set, makes the client compare its recorded
+		// spend against the wallet at most this often, in the background of
+		// task creation, and book the difference as spend.
+		ReconcileInterval time.Duration
+	}
+
+	// BudgetWindow caps the spend within any Period.
+	BudgetWindow struct {
+		Period time.Duration
+		Max    Balance
+	}
+
+	BudgetMode int
+
+	// BudgetEvent describes a task that exceeds a cap.
+	BudgetEvent struct {
+		TaskType string
+		Price    Balance
+		// Period is the exceeded window, or 0 for the total cap.
+		Period time.Duration
+		Spent  Balance
+		Max    Balance
+	}
+
+	// BudgetUsage is a snapshot of the recorded spend.
+	BudgetUsage struct {
+		Total   Balance
+		Windows []BudgetWindowUsage
+	}
+
+	BudgetWindowUsage struct {
+		BudgetWindow
+		Spent Balance
+	}
+
+	budget struct {
+		Budget
+
+		mu      sync.Mutex
+		records []spendRecord
+		total   Balance
+
+		reconciling   bool
+		lastReconcile time.Time
+		baseWallet    Balance
+		baseTotal     Balance
+		hasBase       bool
+	}
+
+	spendRecord struct {
+		at     time.Time
+		amount Balance
+	}
+)
+
+// WithBudget guards task creation with b.
+func WithBudget(b Budget) Option {
+	return func(c *client) {
+		c.budget = &budget{Budget: b}
+	}
+}
+
+// BudgetUsage returns the spend recorded by the client's budget. It is the
+// zero value without WithBudget.
+func (c *Client) BudgetUsage() BudgetUsage {
+	if c.budget == nil {
+		return BudgetUsage{}
+	}
+	return c.budget.usage(time.Now())
+}
+
+// ReconcileBudget compares the wallet against the spend recorded since the
+// previous reconciliation and books the difference, e.g. tasks created by
+// other clients or prices that changed. The first call only records the
+// wallet. Top-ups are ignored.
+func (c *Client) ReconcileBudget(ctx context.Context) error {
+	if c.budget == nil {
+		return nil
+	}
+	result, err := c.Balance(ctx)
+	if err != nil {
+		return fmt.Errorf("reconcile budget: %w", err)
+	}
+	wallet, err := result.Amount()
+	if err != nil {
+		return fmt.Errorf("reconcile budget: %w", err)
+	}
+	c.budget.reconcile(wallet, time.Now())
+	return nil
+}
+
+// reserveBudget books the price of a taskType task before it is created.
+// The returned function gives the reservation back if the API refused the
+// task. Both are no-ops without a budget.
+func (c *Client) reserveBudget(ctx context.Context, taskType string) (refund func(error), err error) {
+	b := c.budget
+	if b == nil {
+		return func(error) {}, nil
+	}
+	if b.ReconcileInterval > 0 {
+		c.maybeReconcile(ctx)
+	}
+
+	now := time.Now()
+	price := b.price(taskType)
+	record, events := b.reserve(taskType, price, now)
+	for _, e := range events {
+		if b.OnExceeded != nil {
+			b.OnExceeded(e)
+		}
+	}
+	if record == nil {
+		e := events[0]
+		if e.Period == 0 {
+			return nil, fmt.Errorf("%w: %s costs %s, total spend %s of %s", ErrBudgetExceeded, taskType, price, e.Spent, e.Max)
+		}
+		return nil, fmt.Errorf("%w: %s costs %s, spend in last %s is %s of %s", ErrBudgetExceeded, taskType, price, e.Period, e.Spent, e.Max)
+	}
+
+	return func(err error) {
+		if isRefusal(err) {
+			b.refund(record)
+		}
+	}, nil
+}
+
+// isRefusal reports whether err proves that a task was not created: the API
+// refused it without returning a task id, the call gave up before it was
+// sent, e.g. while waiting for a client-side limit, or the request was never
+// processed. A 5xx proves nothing, since it may come from a proxy in front of
+// a server that did create the task.
+func isRefusal(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode < http.StatusInternalServerError && apiErr.TaskId == ""
+	}
+	var notSent *notSentError
+	return errors.As(err, &notSent) || isUnprocessed(err)
+}
+
+func (c *Client) maybeReconcile(ctx context.Context) {
+	b := c.budget
+	b.mu.Lock()
+	due := !b.reconciling && time.Since(b.lastReconcile) >= b.ReconcileInterval
+	if due {
+		b.reconciling = true
+	}
+	b.mu.Unlock()
+	if !due {
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), reconcileTimeout)
+		defer cancel()
+		err := c.ReconcileBudget(ctx)
+		if err != nil && c.logger != nil {
+			c.logger.LogAttrs(ctx, slog.LevelWarn, "salamoonder budget reconciliation failed", slog.String("error", err.Error()))
+		}
+
+		b.mu.Lock()
+		b.reconciling = false
+		b.lastReconcile = time.Now()
+		b.mu.Unlock()
+	}()
+}
+
+func (b *budget) price(taskType string) Balance {
+	if p, ok := b.Prices[taskType]; ok {
+		return p
+	}
+	return b.DefaultPrice
+}
+
+// reserve books price unless a cap forbids it in hard-stop mode. It returns
+// the booked record, or nil, and an event for every exceeded cap.
+func (b *budget) reserve(taskType string, price Balance, now time.Time) (*spendRecord, []BudgetEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.prune(now)
+	var events []BudgetEvent
+	if !b.Total.IsZero() && b.total.Add(price).GreaterThan(b.Total) {
+		events = append(events, BudgetEvent{TaskType: taskType, Price: price, Spent: b.total, Max: b.Total})
+	}
+	for _, w := range b.Windows {
+		spent := b.spentSince(now.Add(-w.Period))
+		if spent.Add(price).GreaterThan(w.Max) {
+			events = append(events, BudgetEvent{TaskType: taskType, Price: price, Period: w.Period, Spent: spent, Max: w.Max})
+		}
+	}
+	if len(events) > 0 && b.Mode == BudgetHardStop {
+		return nil, events
+	}
+
+	r := &spendRecord{at: now, amount: price}
+	if len(b.Windows) > 0 {
+		b.records = append(b.records, *r)
+	}
+	b.total = b.total.Add(price)
+	return r, events
+}
+
+// refund gives back a reservation. The total is always reduced; the record,
+// which only serves window accounting, is dropped if it was not pruned yet.
+func (b *budget) refund(r *spendRecord) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.total = b.total.Sub(r.amount)
+	for i := len(b.records) - 1; i >= 0; i-- {
+		if b.records[i] == *r {
+			b.records = append(b.records[:i], b.records[i+1:]...)
+			return
+		}
+	}
+}
+
+func (b *budget) reconcile(wallet Balance, now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.hasBase {
+		actual := b.baseWallet.Sub(wallet)
+		recorded := b.total.Sub(b.baseTotal)
+		if actual.Sign() >= 0 {
+			if drift := actual.Sub(recorded); !drift.IsZero() {
+				if len(b.Windows) > 0 {
+					b.records = append(b.records, spendRecord{at: now, amount: drift})
+				}
+				b.total = b.total.Add(drift)
+			}
+		}
+	}
+	b.baseWallet, b.baseTotal, b.hasBase = wallet, b.total, true
+}
+
+func (b *budget) usage(now time.Time) BudgetUsage {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.prune(now)
+	u := BudgetUsage{Total: b.total}
+	for _, w := range b.Windows {
+		u.Windows = append(u.Windows, BudgetWindowUsage{BudgetWindow: w, Spent: b.spentSince(now.Add(-w.Period))})
+	}
+	return u
+}
+
+// spentSince sums the records after t. b.mu must be held.
+func (b *budget) spentSince(t time.Time) Balance {
+	var sum Balance
+	for _, r := range b.records {
+		if r.at.After(t) {
+			sum = sum.Add(r.amount)
+		}
+	}
+	return sum
+}
+
+// prune drops records older than the longest window. b.mu must be held.
+func (b *budget) prune(now time.Time) {
+	var longest time.Duration
+	for _, w := range b.Windows {
+		longest = max(longest, w.Period)
+	}
+	cutoff := now.Add(-longest)
+	i := 0
+	for i < len(b.records) && !b.records[i].at.After(cutoff) {
+		i++
+	}
+	b.records = b.records[i:]
+}
@@ -0,0 +1,233 @@
+package salamoonder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// budgetWallet is the wallet after spending spent out of 100.
+func budgetWallet(spent string) string {
+	return MustParseBalance("100").Sub(MustParseBalance(spent)).String()
+}
+
+var budgetOptions = KasadaStandardOptions{Pjs: "https://example.com/p.js"}
+
+func TestBudget_WindowHardStop(t *testing.T) {
+	api := &fakeAPI{}
+	var events []BudgetEvent
+	c, closeFn := newTestClient(t, api.ServeHTTP, WithBudget(Budget{
+		Prices:       map[string]Balance{"KasadaCaptchaSolver": MustParseBalance("0.4")},
+		DefaultPrice: MustParseBalance("10"),
+		Windows:      []BudgetWindow{{Period: time.Minute, Max: MustParseBalance("1")}},
+		OnExceeded:   func(e BudgetEvent) { events = append(events, e) },
+	}))
+	defer closeFn()
+
+	for i := range 2 {
+		if _, err := c.CreateTask(context.Background(), budgetOptions); err != nil {
+			t.Fatalf("CreateTask() #%d error: %v", i, err)
+		}
+	}
+	_, err := c.CreateTask(context.Background(), budgetOptions)
+	if !errors.Is(err, ErrBudgetExceeded) {
+		t.Fatalf("errors.Is(err, ErrBudgetExceeded) = false, want true; err = %v", err)
+	}
+	if n := api.creates.Load(); n != 2 {
+		t.Errorf("createTask calls = %d, want 2", n)
+	}
+
+	if len(events) != 1 || events[0].Period != time.Minute || events[0].Spent.String() != "0.80" {
+		t.Errorf("events = %+v, want one minute-window event at 0.80", events)
+	}
+	u := c.BudgetUsage()
+	if u.Total.String() != "0.80" || len(u.Windows) != 1 || u.Windows[0].Spent.String() != "0.80" {
+		t.Errorf("BudgetUsage() = %+v, want 0.80 spent", u)
+	}
+}
+
+func TestBudget_TotalCapAndDefaultPrice(t *testing.T) {
+	api := &fakeAPI{}
+	c, closeFn := newTestClient(t, api.ServeHTTP, WithBudget(Budget{
+		DefaultPrice: MustParseBalance("1"),
+		Total:        MustParseBalance("1"),
+	}))
+	defer closeFn()
+
+	if _, err := c.CreateTask(context.Background(), budgetOptions); err != nil {
+		t.Fatalf("CreateTask() error: %v", err)
+	}
+	if _, err := Solve[KasadaStandardSolution](c, context.Background(), budgetOptions); !errors.Is(err, ErrBudgetExceeded) {
+		t.Fatalf("errors.Is(err, ErrBudgetExceeded) = false, want true; err = %v", err)
+	}
+	if n := api.creates.Load(); n != 1 {
+		t.Errorf("createTask calls = %d, want 1", n)
+	}
+}
+
+func TestBudget_WarnMode(t *testing.T) {
+	api := &fakeAPI{}
+	var events int
+	c, closeFn := newTestClient(t, api.ServeHTTP, WithBudget(Budget{
+		DefaultPrice: MustParseBalance("1"),
+		Total:        MustParseBalance("1"),
+		Mode:         BudgetWarn,
+		OnExceeded:   func(BudgetEvent) { events++ },
+	}))
+	defer closeFn()
+
+	for range 3 {
+		if _, err := c.CreateTask(context.Background(), budgetOptions); err != nil {
+			t.Fatalf("CreateTask() error: %v", err)
+		}
+	}
+	if events != 2 || api.creates.Load() != 3 {
+		t.Errorf("events = %d, creates = %d; want 2, 3", events, api.creates.Load())
+	}
+	if total := c.BudgetUsage().Total.String(); total != "3.00" {
+		t.Errorf("Total = %s, want 3.00", total)
+	}
+}
+
+func TestBudget_RefundOnAPIError(t *testing.T) {
+	h := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"error_code":1,"error_description":"insufficient funds"}`))
+	}
+	c, closeFn := newTestClient(t, h, WithBudget(Budget{DefaultPrice: MustParseBalance("1")}))
+	defer closeFn()
+
+	if _, err := c.CreateTask(context.Background(), budgetOptions); err == nil {
+		t.Fatal("CreateTask() error = nil, want API error")
+	}
+	if total := c.BudgetUsage().Total; !total.IsZero() {
+		t.Errorf("Total = %s, want 0 after refused task", total)
+	}
+}
+
+func TestBudget_NoRefundOnServerError(t *testing.T) {
+	h := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}
+	c, closeFn := newTestClient(t, h, WithBudget(Budget{DefaultPrice: MustParseBalance("1")}))
+	defer closeFn()
+
+	if _, err := c.CreateTask(context.Background(), budgetOptions); err == nil {
+		t.Fatal("CreateTask() error = nil, want API error")
+	}
+	if total := c.BudgetUsage().Total.String(); total != "1.00" {
+		t.Errorf("Total = %s, want 1.00 after a 502 that may have created the task", total)
+	}
+}
+
+func TestBudget_RefundWhenNeverSent(t *testing.T) {
+	var creates atomic.Int32
+	release := make(chan struct{})
+	h := func(w http.ResponseWriter, r *http.Request) {
+		creates.Add(1)
+		<-release
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"error_code":0,"taskId":"task-1"}`))
+	}
+	c, closeFn := newTestClient(t, h,
+		WithRateLimit(Limit{MaxConcurrent: 1}),
+		WithBudget(Budget{DefaultPrice: MustParseBalance("1")}),
+	)
+	defer closeFn()
+	defer close(release)
+
+	go c.CreateTask(context.Background(), budgetOptions)
+	for creates.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// These time out waiting for the only slot and never reach the server.
+	for i := 0; i < 5; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		_, err := c.CreateTask(ctx, budgetOptions)
+		cancel()
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("errors.Is(err, context.DeadlineExceeded) = false, want true; err = %v", err)
+		}
+	}
+	if total := c.BudgetUsage().Total.String(); total != "1.00" {
+		t.Errorf("Total = %s, want 1.00 for the one task in flight", total)
+	}
+	if n := creates.Load(); n != 1 {
+		t.Errorf("tasks created = %d, want 1", n)
+	}
+}
+
+func TestBudget_RefundAfterRecordPruned(t *testing.T) {
+	b := &budget{Budget: Budget{Windows: []BudgetWindow{{Period: time.Minute, Max: MustParseBalance("10")}}}}
+	now := time.Now()
+	record, _ := b.reserve("KasadaCaptchaSolver", MustParseBalance("1"), now)
+
+	// The record leaves the window before the refund arrives.
+	if u := b.usage(now.Add(2 * time.Minute)); u.Windows[0].Spent.String() != "0.00" {
+		t.Fatalf("window spend = %s, want 0.00 after the window passed", u.Windows[0].Spent)
+	}
+	b.refund(record)
+	if total := b.usage(now.Add(2 * time.Minute)).Total; !total.IsZero() {
+		t.Errorf("Total = %s, want 0 after refund", total)
+	}
+}
+
+func TestBudget_Reconcile(t *testing.T) {
+	api := &fakeAPI{}
+	// Another process on the same key spends 2 more than this client records.
+	api.Wallet = func() string {
+		if api.creates.Load() == 0 {
+			return budgetWallet("0")
+		}
+		return budgetWallet(fmt.Sprint(api.creates.Load() + 2))
+	}
+	c, closeFn := newTestClient(t, api.ServeHTTP, WithBudget(Budget{DefaultPrice: MustParseBalance("1")}))
+	defer closeFn()
+
+	if err := c.ReconcileBudget(context.Background()); err != nil {
+		t.Fatalf("ReconcileBudget() error: %v", err)
+	}
+	c.CreateTask(context.Background(), budgetOptions)
+	if err := c.ReconcileBudget(context.Background()); err != nil {
+		t.Fatalf("ReconcileBudget() error: %v", err)
+	}
+	if total := c.BudgetUsage().Total.String(); total != "3.00" {
+		t.Errorf("Total = %s, want 3.00 after reconciliation", total)
+	}
+
+	// No further drift: reconciling again changes nothing.
+	c.ReconcileBudget(context.Background())
+	if total := c.BudgetUsage().Total.String(); total != "3.00" {
+		t.Errorf("Total = %s, want 3.00", total)
+	}
+}
+
+func TestBudget_BackgroundReconcile(t *testing.T) {
+	var balances atomic.Int32
+	api := &fakeAPI{Wallet: func() string { return budgetWallet("0") }}
+	counting := func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == EndpointGetBalance {
+			balances.Add(1)
+		}
+		api.ServeHTTP(w, r)
+	}
+	c, closeFn := newTestClient(t, counting, WithBudget(Budget{ReconcileInterval: time.Hour}))
+	defer closeFn()
+
+	for range 3 {
+		c.CreateTask(context.Background(), budgetOptions)
+	}
+	deadline := time.Now().Add(time.Second)
+	for balances.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+	if n := balances.Load(); n != 1 {
+		t.Errorf("balance calls = %d, want 1 within the interval", n)
+	}
+}
@@ -0,0 +1,339 @@
+package salamoonder
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const (
+	defaultCacheSize    = 256
+	defaultRefreshAhead = 30 * time.Second
+)
+
+// refreshTimeout bounds a background refresh, so that a task that never
+// finishes does not block later refreshes of the same entry.
+const refreshTimeout = 5 * time.Minute
+
+type (
+	// CacheEntry is a solution kept by a CacheStore.
+	CacheEntry struct {
+		TaskType  string
+		Solution  json.RawMessage
+		SolvedAt  time.Time
+		ExpiresAt time.Time
+	}
+
+	// CacheStore holds cached solutions by key. Implementations must be safe
+	// for concurrent use; they may drop entries at any time.
+	CacheStore interface {
+		Get(key string) (CacheEntry, bool)
+		Set(key string, entry CacheEntry)
+		Delete(key string)
+	}
+
+	// Expirer is implemented by solutions that are only valid for a limited
+	// time. ExpiresAt returns when a solution solved at solvedAt stops being
+	// valid, or the zero time if it does not say.
+	Expirer interface {
+		ExpiresAt(solvedAt time.Time) time.Time
+	}
+
+	// CacheOption configures the solution cache enabled by WithCache.
+	CacheOption func(*cacheConfig)
+
+	cacheConfig struct {
+		store        CacheStore
+		ttls         map[string]time.Duration
+		refreshAhead time.Duration
+	}
+
+	solutionCache struct {
+		cacheConfig
+
+		mu         sync.Mutex
+		refreshing map[string]struct{}
+	}
+
+	// LRUStore is an in-memory CacheStore that evicts the least recently
+	// used entry once it holds its capacity.
+	LRUStore struct {
+		mu       sync.Mutex
+		capacity int
+		order    *list.List
+		items    map[string]*list.Element
+	}
+
+	lruItem struct {
+		key   string
+		entry CacheEntry
+	}
+)
+
+// WithCache makes Solve reuse solutions that are still valid for the same
+// task type and options. Only solutions with a known lifetime are cached:
+// those implementing Expirer, such as Reese84SubmitPayloadSolution and
+// TwitchIntegritySolution, and task types given a TTL with WithCacheTTL.
+func WithCache(opts ...CacheOption) Option {
+	return func(c *client) {
+		cfg := cacheConfig{refreshAhead: defaultRefreshAhead}
+		for _, opt := range opts {
+			if opt != nil {
+				opt(&cfg)
+			}
+		}
+		if cfg.store == nil {
+			cfg.store = NewLRUStore(defaultCacheSize)
+		}
+		c.cache = &solutionCache{
+			cacheConfig: cfg,
+			refreshing:  make(map[string]struct{}),
+		}
+	}
+}
+
+// WithCacheStore sets where solutions are kept. The default is an LRUStore
+// of 256 entries.
+func WithCacheStore(store CacheStore) CacheOption {
+	return func(cfg *cacheConfig) {
+		cfg.store = store
+	}
+}
+
+// WithCacheTTL caches solutions of taskType for ttl after they were solved.
+// It applies to solutions that do not implement Expirer or do not know
+// their expiry.
+func WithCacheTTL(taskType string, ttl time.Duration) CacheOption {
+	return func(cfg *cacheConfig) {
+		if cfg.ttls == nil {
+			cfg.ttls = make(map[string]time.Duration)
+		}
+		cfg.ttls[taskType] = ttl
+	}
+}
+
+// WithRefreshAhead sets how long before expiry a cached solution is solved
+// again in the background when Solve returns it, so that callers keep
+// getting valid solutions without waiting. Within half of d of expiry a
+// cached solution is no longer returned, since the caller may not get to use
+// it in time; Solve solves a new one instead. The default is 30 seconds; 0
+// disables background refreshes.
+func WithRefreshAhead(d time.Duration) CacheOption {
+	return func(cfg *cacheConfig) {
+		cfg.refreshAhead = d
+	}
+}
+
+// ExpiresAt is RenewInSec seconds after the solution was solved.
+func (s Reese84SubmitPayloadSolution) ExpiresAt(solvedAt time.Time) time.Time {
+	if s.RenewInSec <= 0 {
+		return time.Time{}
+	}
+	return solvedAt.Add(time.Duration(s.RenewInSec) * time.Second)
+}
+
+// ExpiresAt converts ExpirationAt, a Unix timestamp in seconds or
+// milliseconds.
+func (s TwitchIntegritySolution) ExpiresAt(time.Time) time.Time {
+	switch {
+	case s.ExpirationAt <= 0:
+		return time.Time{}
+	case s.ExpirationAt >= 1e12:
+		return time.UnixMilli(s.ExpirationAt)
+	default:
+		return time.Unix(s.ExpirationAt, 0)
+	}
+}
+
+// InvalidateCache drops the cached solution for options, e.g. after the
+// target site rejected it.
+func (c *Client) InvalidateCache(options any) error {
+	if c.cache == nil {
+		return nil
+	}
+	key, err := cacheKey(options)
+	if err != nil {
+		return err
+	}
+	c.cache.store.Delete(key)
+	return nil
+}
+
+// cacheKey is the task type and a hash of options.
+func cacheKey(options any) (string, error) {
+	taskType, ok := TaskTypeOf(options)
+	if !ok {
+		return "", &MethodError{OptionsValue: options}
+	}
+	hash, err := OptionsHash(options)
+	if err != nil {
+		return "", err
+	}
+	return taskType + "/" + hash, nil
+}
+
+// solveCached returns a cached solution for options that is valid for at
+// least half the refresh-ahead window, or solves and caches a new one. A hit
+// within the refresh-ahead window starts a background refresh.
+func solveCached[TS TaskSolution, TO TaskOptions](c *Client, ctx context.Context, options TO, opts []PollOption) (*TaskResult[TS], error) {
+	key, err := cacheKey(options)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if entry, ok := c.cache.store.Get(key); ok {
+		var solution TS
+		remaining := entry.ExpiresAt.Sub(now)
+		if remaining > c.cache.refreshAhead/2 && json.Unmarshal(entry.Solution, &solution) == nil {
+			if remaining <= c.cache.refreshAhead {
+				c.cache.refresh(key, func() {
+					ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), refreshTimeout)
+					defer cancel()
+					if _, err := solveAndCache[TS](c, ctx, key, options, opts); err != nil && c.logger != nil {
+						c.logger.LogAttrs(ctx, slog.LevelWarn, "salamoonder cache refresh failed",
+							slog.String("task_type", entry.TaskType),
+							slog.String("error", err.Error()),
+						)
+					}
+				})
+			}
+			return &TaskResult[TS]{
+				Solution:  solution,
+				Status:    TaskStatusReady,
+				FromCache: true,
+			}, nil
+		}
+		c.cache.store.Delete(key)
+	}
+
+	return solveCoalesced(c, ctx, options, func(ctx context.Context) (*TaskResult[TS], error) {
+		return solveAndCache[TS](c, ctx, key, options, opts)
+	})
+}
+
+func solveAndCache[TS TaskSolution, TO TaskOptions](c *Client, ctx context.Context, key string, options TO, opts []PollOption) (*TaskResult[TS], error) {
+	result, err := solve[TS](c, ctx, options, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	solvedAt := time.Now()
+	taskType := getTaskTypeFromOptions(options)
+	expiresAt := c.cache.expiresAt(taskType, result.Solution, solvedAt)
+	if !expiresAt.After(solvedAt) {
+		return result, nil
+	}
+	data, err := json.Marshal(result.Solution)
+	if err != nil {
+		return result, nil
+	}
+	c.cache.store.Set(key, CacheEntry{
+		TaskType:  taskType,
+		Solution:  data,
+		SolvedAt:  solvedAt,
+		ExpiresAt: expiresAt,
+	})
+	return result, nil
+}
+
+// expiresAt is the solution's own expiry if it has one, otherwise solvedAt
+// plus the task type's TTL. The zero time means the solution is not cached.
+func (sc *solutionCache) expiresAt(taskType string, solution any, solvedAt time.Time) time.Time {
+	if e, ok := solution.(Expirer); ok {
+		if t := e.ExpiresAt(solvedAt); !t.IsZero() {
+			return t
+		}
+	}
+	if ttl := sc.ttls[taskType]; ttl > 0 {
+		return solvedAt.Add(ttl)
+	}
+	return time.Time{}
+}
+
+// refresh runs solve in the background unless a refresh of key is already
+// running.
+func (sc *solutionCache) refresh(key string, solve func()) {
+	sc.mu.Lock()
+	if _, ok := sc.refreshing[key]; ok {
+		sc.mu.Unlock()
+		return
+	}
+	sc.refreshing[key] = struct{}{}
+	sc.mu.Unlock()
+
+	go func() {
+		defer func() {
+			sc.mu.Lock()
+			delete(sc.refreshing, key)
+			sc.mu.Unlock()
+		}()
+		solve()
+	}()
+}
+
+// NewLRUStore returns an empty LRUStore holding up to capacity entries.
+func NewLRUStore(capacity int) *LRUStore {
+	if capacity <= 0 {
+		capacity = defaultCacheSize
+	}
+	return &LRUStore{
+		capacity: capacity,
+		order:    list.New(),
+		items:    make(map[string]*list.Element),
+	}
+}
+
+// Get returns the entry for key and marks it as recently used.
+func (s *LRUStore) Get(key string) (CacheEntry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	el, ok := s.items[key]
+	if !ok {
+		return CacheEntry{}, false
+	}
+	s.order.MoveToFront(el)
+	return el.Value.(*lruItem).entry, true
+}
+
+// Set stores entry under key, evicting the least recently used entry if
+// the store is full.
+func (s *LRUStore) Set(key string, entry CacheEntry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if el, ok := s.items[key]; ok {
+		el.Value.(*lruItem).entry = entry
+		s.order.MoveToFront(el)
+		return
+	}
+	s.items[key] = s.order.PushFront(&lruItem{key: key, entry: entry})
+	if s.order.Len() > s.capacity {
+		oldest := s.order.Back()
+		s.order.Remove(oldest)
+		delete(s.items, oldest.Value.(*lruItem).key)
+	}
+}
+
+// Delete removes the entry for key.
+func (s *LRUStore) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if el, ok := s.items[key]; ok {
+		s.order.Remove(el)
+		delete(s.items, key)
+	}
+}
+
+// Len returns the number of entries.
+func (s *LRUStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.order.Len()
+}
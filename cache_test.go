@@ -0,0 +1,208 @@
+package salamoonder
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSolve_CacheHit(t *testing.T) {
+	api := &fakeAPI{Solution: `{"token":"tok","renewInSec":600}`}
+	c, closeFn := newTestClient(t, api.ServeHTTP, WithCache())
+	defer closeFn()
+	options := Reese84Options{Website: "https://example.com", SubmitPayload: true}
+
+	first, err := Solve[Reese84SubmitPayloadSolution](c, context.Background(), options)
+	if err != nil {
+		t.Fatalf("Solve() error: %v", err)
+	}
+	second, err := Solve[Reese84SubmitPayloadSolution](c, context.Background(), options)
+	if err != nil {
+		t.Fatalf("Solve() error: %v", err)
+	}
+
+	if first.FromCache || !second.FromCache {
+		t.Errorf("FromCache = %v, %v; want false, true", first.FromCache, second.FromCache)
+	}
+	if second.Solution.Token != "tok" || second.Status != TaskStatusReady {
+		t.Errorf("cached result = %+v, want the first solution", second)
+	}
+	if n := api.creates.Load(); n != 1 {
+		t.Errorf("tasks created = %d, want 1", n)
+	}
+
+	// Different options are a different entry.
+	other := Reese84Options{Website: "https://example.org", SubmitPayload: true}
+	if _, err := Solve[Reese84SubmitPayloadSolution](c, context.Background(), other); err != nil {
+		t.Fatalf("Solve() error: %v", err)
+	}
+	if n := api.creates.Load(); n != 2 {
+		t.Errorf("tasks created = %d, want 2", n)
+	}
+}
+
+func TestSolve_CacheSkipsSolutionsWithoutExpiry(t *testing.T) {
+	api := &fakeAPI{}
+	c, closeFn := newTestClient(t, api.ServeHTTP, WithCache())
+	defer closeFn()
+	options := KasadaStandardOptions{Pjs: "https://example.com/p.js"}
+
+	for range 2 {
+		if _, err := Solve[KasadaStandardSolution](c, context.Background(), options); err != nil {
+			t.Fatalf("Solve() error: %v", err)
+		}
+	}
+	if n := api.creates.Load(); n != 2 {
+		t.Errorf("tasks created = %d, want 2", n)
+	}
+}
+
+func TestSolve_CacheTTLExpires(t *testing.T) {
+	api := &fakeAPI{}
+	c, closeFn := newTestClient(t, api.ServeHTTP, WithCache(
+		WithCacheTTL("KasadaCaptchaSolver", 20*time.Millisecond),
+		WithRefreshAhead(0),
+	))
+	defer closeFn()
+	options := KasadaStandardOptions{Pjs: "https://example.com/p.js"}
+
+	for range 2 {
+		if _, err := Solve[KasadaStandardSolution](c, context.Background(), options); err != nil {
+			t.Fatalf("Solve() error: %v", err)
+		}
+	}
+	if n := api.creates.Load(); n != 1 {
+		t.Fatalf("tasks created = %d, want 1", n)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	result, err := Solve[KasadaStandardSolution](c, context.Background(), options)
+	if err != nil {
+		t.Fatalf("Solve() error: %v", err)
+	}
+	if result.FromCache {
+		t.Error("FromCache = true after expiry, want false")
+	}
+	if n := api.creates.Load(); n != 2 {
+		t.Errorf("tasks created = %d, want 2", n)
+	}
+}
+
+func TestSolve_CacheRefreshAhead(t *testing.T) {
+	api := &fakeAPI{}
+	c, closeFn := newTestClient(t, api.ServeHTTP, WithCache(
+		WithCacheTTL("KasadaCaptchaSolver", time.Hour),
+		WithRefreshAhead(90*time.Minute),
+	))
+	defer closeFn()
+	options := KasadaStandardOptions{Pjs: "https://example.com/p.js"}
+
+	if _, err := Solve[KasadaStandardSolution](c, context.Background(), options); err != nil {
+		t.Fatalf("Solve() error: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	result, err := Solve[KasadaStandardSolution](c, ctx, options, WithPollInterval(time.Millisecond))
+	cancel()
+	if err != nil {
+		t.Fatalf("Solve() error: %v", err)
+	}
+	if !result.FromCache {
+		t.Fatal("FromCache = false, want a cached result while refreshing")
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for api.creates.Load() < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if n := api.creates.Load(); n != 2 {
+		t.Errorf("tasks created = %d, want a background refresh despite the canceled context", n)
+	}
+}
+
+func TestSolve_CacheNearExpiryIsMiss(t *testing.T) {
+	api := &fakeAPI{}
+	c, closeFn := newTestClient(t, api.ServeHTTP, WithCache(
+		WithCacheTTL("KasadaCaptchaSolver", time.Minute),
+		WithRefreshAhead(time.Hour),
+	))
+	defer closeFn()
+	options := KasadaStandardOptions{Pjs: "https://example.com/p.js"}
+
+	for range 2 {
+		result, err := Solve[KasadaStandardSolution](c, context.Background(), options)
+		if err != nil {
+			t.Fatalf("Solve() error: %v", err)
+		}
+		if result.FromCache {
+			t.Error("FromCache = true for an entry within half the refresh-ahead window, want false")
+		}
+	}
+	if n := api.creates.Load(); n != 2 {
+		t.Errorf("tasks created = %d, want 2", n)
+	}
+}
+
+func TestClient_InvalidateCache(t *testing.T) {
+	api := &fakeAPI{Solution: `{"token":"tok","renewInSec":600}`}
+	c, closeFn := newTestClient(t, api.ServeHTTP, WithCache())
+	defer closeFn()
+	options := Reese84Options{Website: "https://example.com", SubmitPayload: true}
+
+	if _, err := Solve[Reese84SubmitPayloadSolution](c, context.Background(), options); err != nil {
+		t.Fatalf("Solve() error: %v", err)
+	}
+	if err := c.InvalidateCache(options); err != nil {
+		t.Fatalf("InvalidateCache() error: %v", err)
+	}
+	if _, err := Solve[Reese84SubmitPayloadSolution](c, context.Background(), options); err != nil {
+		t.Fatalf("Solve() error: %v", err)
+	}
+	if n := api.creates.Load(); n != 2 {
+		t.Errorf("tasks created = %d, want 2", n)
+	}
+}
+
+func TestLRUStore(t *testing.T) {
+	s := NewLRUStore(2)
+	s.Set("a", CacheEntry{TaskType: "A"})
+	s.Set("b", CacheEntry{TaskType: "B"})
+	s.Get("a")
+	s.Set("c", CacheEntry{TaskType: "C"})
+
+	if _, ok := s.Get("b"); ok {
+		t.Error("Get(b) found an entry, want it evicted as least recently used")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, ok := s.Get(key); !ok {
+			t.Errorf("Get(%s) found nothing, want an entry", key)
+		}
+	}
+	s.Delete("a")
+	if s.Len() != 1 {
+		t.Errorf("Len() = %d, want 1", s.Len())
+	}
+}
+
+func TestSolutionExpiresAt(t *testing.T) {
+	solvedAt := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	want := time.Unix(1735693200, 0)
+
+	tests := []struct {
+		name     string
+		solution Expirer
+		want     time.Time
+	}{
+		{"reese84", Reese84SubmitPayloadSolution{RenewInSec: 3600}, want},
+		{"reese84 without renewal", Reese84SubmitPayloadSolution{}, time.Time{}},
+		{"twitch seconds", TwitchIntegritySolution{ExpirationAt: 1735693200}, want},
+		{"twitch milliseconds", TwitchIntegritySolution{ExpirationAt: 1735693200000}, want},
+		{"twitch without expiration", TwitchIntegritySolution{}, time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.solution.ExpiresAt(solvedAt); !got.Equal(tt.want) {
+				t.Errorf("ExpiresAt() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -4,107 +4,146 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"net/http"
+	"time"
 )
 
+const defaultBaseURL = "https://salamoonder.com/api"
+
 type client struct {
 	baseURL    string
 	apiKey     string
 	httpClient *http.Client
+	userAgent  string
+	timeout    time.Duration
+	logger     *slog.Logger
+
+	retryPolicy           RetryPolicy
+	endpointRetryPolicies map[string]RetryPolicy
+
+	limiters *limiters
+
+	middleware []Middleware
+	handler    Handler
+
+	skipValidation bool
+
+	poller  *poller
+	journal Journal
+	budget  *budget
+	usage   *usage
+	tracer  Tracer
+	cache   *solutionCache
+	flights *flightGroup
+
+	httpTrace      bool
+	strictDecoding bool
 }
 
 type Client struct {
 	*client
 }
 
-func New(apiKey string, httpClient *http.Client) (*Client, error) {
+// New creates a client for the given API key. Without options it talks to
+// https://salamoonder.com/api using a plain http.Client.
+func New(apiKey string, opts ...Option) (*Client, error) {
 	if apiKey == "" {
 		return nil, ErrNoApiKey
 	}
 
-	if httpClient == nil {
-		httpClient = &http.Client{}
+	c := &client{
+		baseURL: defaultBaseURL,
+		apiKey:  apiKey,
+		usage:   newUsage(),
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(c)
+		}
 	}
 
-	return &Client{
-		client: &client{
-			baseURL:    "https://salamoonder.com/api",
-			apiKey:     apiKey,
-			httpClient: httpClient,
-		},
-	}, nil
+	if c.httpClient == nil {
+		c.httpClient = &http.Client{}
+	}
+	c.handler = chain(c.send, c.middleware)
+
+	client := &Client{
+		client: c,
+	}
+	c.poller = newPoller(client)
+	return client, nil
 }
 
-func (c *Client) Balance(ctx context.Context) (*CreateTaskBalanceResult, error) {
+// NewWithHTTPClient is the former New(apiKey, httpClient) constructor.
+//
+// Deprecated: Use New(apiKey, WithHTTPClient(httpClient)).
+func NewWithHTTPClient(apiKey string, httpClient *http.Client) (*Client, error) {
+	return New(apiKey, WithHTTPClient(httpClient))
+}
+
+func (c *Client) Balance(ctx context.Context) (_ *CreateTaskBalanceResult, err error) {
+	ctx, span := c.startSpan(ctx, SpanBalance)
+	defer func() { span.End(err) }()
+
 	req := CreateTaskRequest{
 		ApiKey: c.apiKey,
 	}
 
 	var result CreateTaskBalanceResult
-	if err := c.postJSON(ctx, "/getBalance", req, &result); err != nil {
+	if err := c.postJSON(ctx, EndpointGetBalance, req, &result); err != nil {
 		return nil, err
 	}
 
-	if result.ErrorCode != 0 {
-		return &result, &APIError{
-			StatusCode: http.StatusOK,
-			Msg:        result.ErrorDescription,
-		}
-	}
-
 	return &result, nil
 }
 
 func (c *Client) CreateTask(ctx context.Context, options any) (*CreateTaskResult, error) {
-	switch opts := options.(type) {
-	case KasadaStandardOptions:
-		return createTaskGeneric(c, ctx, opts)
-	case KasadaPayloadOptions:
-		return createTaskGeneric(c, ctx, opts)
-	case AkamaiWebOptions:
-		return createTaskGeneric(c, ctx, opts)
-	case AkamaiSBSDOptions:
-		return createTaskGeneric(c, ctx, opts)
-	case Reese84Options:
-		return createTaskGeneric(c, ctx, opts)
-	case UutmvcOptions:
-		return createTaskGeneric(c, ctx, opts)
-	case DataDomeInterstitialOptions:
-		return createTaskGeneric(c, ctx, opts)
-	case DataDomeSliderOptions:
-		return createTaskGeneric(c, ctx, opts)
-	case TwitchScraperOptions:
-		return createTaskGeneric(c, ctx, opts)
-	case TwitchIntegrityOptions:
-		return createTaskGeneric(c, ctx, opts)
-	default:
+	t := registry.lookup(options)
+	if t == nil {
 		return nil, &MethodError{
 			OptionsValue: options,
 		}
 	}
+	return t.create(c, ctx, options)
 }
 
-func (c *Client) Task(ctx context.Context, taskId string) (*TaskResultRaw, error) {
+func (c *Client) Task(ctx context.Context, taskId string) (_ *TaskResultRaw, err error) {
+	ctx, span := c.startSpan(ctx, SpanTask, slog.String(AttrTaskId, taskId))
+	defer func() { span.End(err) }()
+
 	var result TaskResultRaw
 	req := TaskRequest{
 		APIKey: c.apiKey,
 		TaskId: taskId,
 	}
-	if err := c.postJSON(ctx, "/getTaskResult", req, &result); err != nil {
+	if err := c.postJSON(ctx, EndpointGetTaskResult, req, &result); err != nil {
+		c.taskResultObserved(ctx, taskId, "", err)
 		return nil, err
 	}
-	if result.ErrorId != 0 {
-		return &result, &APIError{
-			StatusCode: http.StatusOK,
-			TaskId:     taskId,
-			Msg:        result.Status,
-		}
-	}
+	c.taskResultObserved(ctx, taskId, result.Status, nil)
+	span.SetAttributes(slog.String(AttrStatus, result.Status.String()))
 	return &result, nil
 }
 
-func createTaskGeneric[TO TaskOptions](c *Client, ctx context.Context, options TO) (*CreateTaskResult, error) {
-	taskType := getTaskTypeFromOptions(options)
+func createTaskGeneric[TO TaskOptions](c *Client, ctx context.Context, options TO) (_ *CreateTaskResult, err error) {
+	t := registry.lookup(options)
+	if t == nil {
+		return nil, &MethodError{
+			OptionsValue: options,
+		}
+	}
+	taskType := t.name
+	ctx = withTaskType(ctx, taskType)
+
+	ctx, span := c.startSpan(ctx, SpanCreateTask)
+	defer func() { span.End(err) }()
+
+	if !c.skipValidation {
+		if err := validateOptions(options); err != nil {
+			return nil, err
+		}
+	}
 
 	optionsJSON, err := json.Marshal(options)
 	if err != nil {
@@ -129,68 +168,56 @@ func createTaskGeneric[TO TaskOptions](c *Client, ctx context.Context, options T
 		Task:   taskPayload,
 	}
 
-	var result CreateTaskResult
-	if err := c.postJSON(ctx, "/createTask", req, &result); err != nil {
+	refund, err := c.reserveBudget(ctx, taskType)
+	if err != nil {
 		return nil, err
 	}
 
-	if result.ErrorCode != 0 {
-		return &result, &APIError{
-			StatusCode: http.StatusOK,
-			TaskId:     result.TaskId,
-			Msg:        result.ErrorDescription,
-		}
+	var result CreateTaskResult
+	if err := c.postJSON(ctx, EndpointCreateTask, req, &result); err != nil {
+		refund(err)
+		return nil, err
 	}
+	span.SetAttributes(slog.String(AttrTaskId, result.TaskId))
+	taskClockFromContext(ctx).createdTask()
+	c.usage.created(taskType, result.TaskId, c.price(taskType))
+	c.journalCreated(ctx, taskType, result.TaskId, options)
 
 	return &result, nil
 }
 
-func GetTaskResult[TS TaskSolution](c *Client, ctx context.Context, taskId string) (*TaskResult[TS], error) {
+func GetTaskResult[TS TaskSolution](c *Client, ctx context.Context, taskId string) (_ *TaskResult[TS], err error) {
+	ctx, span := c.startSpan(ctx, SpanGetTaskResult, slog.String(AttrTaskId, taskId))
+	defer func() { span.End(err) }()
+
 	var result TaskResult[TS]
 	req := TaskRequest{
 		APIKey: c.apiKey,
 		TaskId: taskId,
 	}
-	if err := c.postJSON(ctx, "/getTaskResult", req, &result); err != nil {
+	if err := c.postJSON(ctx, EndpointGetTaskResult, req, &result); err != nil {
+		c.taskResultObserved(ctx, taskId, "", err)
 		return nil, err
 	}
-	if result.ErrorId != 0 {
-		return &result, &APIError{
-			StatusCode: http.StatusOK,
-			TaskId:     taskId,
-			Msg:        result.Status,
-		}
-	}
+	c.taskResultObserved(ctx, taskId, result.Status, nil)
+	span.SetAttributes(slog.String(AttrStatus, result.Status.String()))
 	return &result, nil
 }
 
-func (c *client) setBaseURL(url string) {
-	c.baseURL = url
+// taskResultObserved feeds the outcome of a /getTaskResult call to the task
+// clock, usage accounting and the journal.
+func (c *Client) taskResultObserved(ctx context.Context, taskId string, status TaskStatus, err error) {
+	if err != nil && isTaskFailure(err) {
+		status = TaskStatusFailed
+	}
+	taskClockFromContext(ctx).polled()
+	c.usage.polled(taskTypeFromContext(ctx), taskId, status)
+	c.journalResult(ctx, taskId, status, err)
 }
 
 func getTaskTypeFromOptions(opts any) string {
-	switch any(opts).(type) {
-	case KasadaStandardOptions:
-		return "KasadaCaptchaSolver"
-	case KasadaPayloadOptions:
-		return "KasadaPayloadSolver"
-	case AkamaiWebOptions:
-		return "AkamaiWebSolver"
-	case AkamaiSBSDOptions:
-		return "AkamaiSBSDSolver"
-	case Reese84Options:
-		return "IncapsulaReese84Solver"
-	case UutmvcOptions:
-		return "IncapsulaUTMVCSolver"
-	case DataDomeInterstitialOptions:
-		return "DataDomeInterstitialSolver"
-	case DataDomeSliderOptions:
-		return "DataDomeSliderSolver"
-	case TwitchScraperOptions:
-		return "Twitch_Scraper"
-	case TwitchIntegrityOptions:
-		return "Twitch_PublicIntegrity"
-	default:
-		return "unknown"
+	if name, ok := TaskTypeOf(opts); ok {
+		return name
 	}
+	return "unknown"
 }
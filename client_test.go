@@ -7,16 +7,16 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 )
 
-func newTestClient(t *testing.T, handler http.HandlerFunc) (*Client, func()) {
+func newTestClient(t *testing.T, handler http.HandlerFunc, opts ...Option) (*Client, func()) {
 	t.Helper()
 	ts := httptest.NewServer(handler)
-	c, err := New("test-api-key", nil)
+	c, err := New("test-api-key", append([]Option{WithBaseURL(ts.URL)}, opts...)...)
 	if err != nil {
 		t.Fatalf("New() error: %v", err)
 	}
-	c.client.setBaseURL(ts.URL)
 	return c, ts.Close
 }
 
@@ -34,6 +34,89 @@ func TestNew_NoApiKey(t *testing.T) {
 	}
 }
 
+func TestNew_Defaults(t *testing.T) {
+	c, err := New("test-api-key", nil)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	if c.baseURL != defaultBaseURL {
+		t.Errorf("baseURL = %q, want %q", c.baseURL, defaultBaseURL)
+	}
+	if c.httpClient == nil {
+		t.Error("httpClient is nil, want default client")
+	}
+}
+
+func TestNew_Options(t *testing.T) {
+	httpClient := &http.Client{}
+	c, err := New("test-api-key",
+		WithBaseURL("http://localhost:8080/api/"),
+		WithHTTPClient(httpClient),
+		WithUserAgent("test-agent"),
+		WithDefaultTimeout(time.Second),
+	)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	if c.baseURL != "http://localhost:8080/api" {
+		t.Errorf("baseURL = %q, want http://localhost:8080/api", c.baseURL)
+	}
+	if c.httpClient != httpClient {
+		t.Error("httpClient was not applied")
+	}
+	if c.userAgent != "test-agent" || c.timeout != time.Second {
+		t.Errorf("userAgent = %q, timeout = %v", c.userAgent, c.timeout)
+	}
+}
+
+func TestNewWithHTTPClient(t *testing.T) {
+	httpClient := &http.Client{}
+	c, err := NewWithHTTPClient("test-api-key", httpClient)
+	if err != nil {
+		t.Fatalf("NewWithHTTPClient() error: %v", err)
+	}
+	if c.httpClient != httpClient {
+		t.Error("httpClient was not applied")
+	}
+}
+
+func TestWithUserAgent(t *testing.T) {
+	var gotUA string
+	h := func(w http.ResponseWriter, r *http.Request) {
+		gotUA = r.Header.Get("User-Agent")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"error_code":0,"error_description":"","wallet":"1.00"}`))
+	}
+
+	c, closeFn := newTestClient(t, h, WithUserAgent("my-service/1.0"))
+	defer closeFn()
+
+	if _, err := c.Balance(context.Background()); err != nil {
+		t.Fatalf("Balance() error: %v", err)
+	}
+	if gotUA != "my-service/1.0" {
+		t.Errorf("User-Agent = %q, want my-service/1.0", gotUA)
+	}
+}
+
+func TestWithDefaultTimeout(t *testing.T) {
+	h := func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(200 * time.Millisecond):
+		}
+	}
+
+	c, closeFn := newTestClient(t, h, WithDefaultTimeout(20*time.Millisecond))
+	defer closeFn()
+
+	_, err := c.Balance(context.Background())
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("errors.Is(err, context.DeadlineExceeded) = false, want true; err = %v", err)
+	}
+}
+
 // ------------------------------------------------------------------
 // Balance
 // ------------------------------------------------------------------
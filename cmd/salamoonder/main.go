@@ -0,0 +1,48 @@
+// Command salamoonder checks the wallet and creates, polls and solves
+// Salamoonder tasks from the command line.
+//
+// Usage:
+//
+//	salamoonder [global flags] <command> [flags] [args]
+//
+// Commands:
+//
+//	balance                      print the wallet balance
+//	types                        list the supported task types
+//	create <type> -json <opts>   create a task and print its ID
+//	get <taskId>                 print the current task result
+//	wait <taskId>                poll until the task is ready or failed
+//	solve <type> -json <opts>    create a task and wait for its solution
+//
+// The API key is read from -key, the SALAMOONDER_API_KEY environment
+// variable or the config file, in that order. The config file defaults to
+// $XDG_CONFIG_HOME/salamoonder/config.json and looks like
+//
+//	{"api_key": "sr-...", "base_url": "https://salamoonder.com/api"}
+//
+// Task options given to -json may be inline JSON, @file or - for stdin.
+//
+// Exit codes:
+//
+//	0  success
+//	1  other error
+//	2  usage error
+//	3  API error
+//	4  invalid task options
+//	5  task failed
+//	6  timed out
+//	7  transport failure
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	os.Exit(run(ctx, os.Args[1:], os.Stdin, os.Stdout, os.Stderr, os.Getenv))
+}
@@ -0,0 +1,348 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	salamoonder "github.com/juanbrotenelle/go_salamoonder"
+)
+
+const (
+	exitOK = iota
+	exitError
+	exitUsage
+	exitAPI
+	exitInvalidOptions
+	exitTaskFailed
+	exitTimeout
+	exitTransport
+)
+
+var errUsage = errors.New("usage error")
+
+type cli struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+	json   bool
+	client *salamoonder.Client
+}
+
+func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer, getenv func(string) string) int {
+	global := flag.NewFlagSet("salamoonder", flag.ContinueOnError)
+	global.SetOutput(stderr)
+	var (
+		key        = global.String("key", "", "API key (default $"+envAPIKey+" or config file)")
+		configPath = global.String("config", "", "config file (default $XDG_CONFIG_HOME/salamoonder/config.json)")
+		baseURL    = global.String("base-url", "", "API base URL")
+		output     = global.String("o", "text", "output format: text or json")
+		timeout    = global.Duration("timeout", 30*time.Second, "timeout of a single API call")
+	)
+	global.Usage = func() {
+		fmt.Fprintln(stderr, "usage: salamoonder [global flags] <balance|types|create|get|wait|solve> [flags] [args]")
+		global.PrintDefaults()
+	}
+	if err := global.Parse(args); err != nil {
+		return exitUsage
+	}
+	if global.NArg() == 0 {
+		global.Usage()
+		return exitUsage
+	}
+	if *output != "text" && *output != "json" {
+		fmt.Fprintf(stderr, "salamoonder: unknown output format %q\n", *output)
+		return exitUsage
+	}
+
+	c := &cli{stdin: stdin, stdout: stdout, stderr: stderr, json: *output == "json"}
+	cmd, cmdArgs := global.Arg(0), global.Args()[1:]
+
+	if cmd != "types" {
+		cfg, err := loadConfig(*configPath, getenv)
+		if err != nil {
+			fmt.Fprintf(stderr, "salamoonder: %v\n", err)
+			return exitUsage
+		}
+		apiKey := firstNonEmpty(*key, getenv(envAPIKey), cfg.APIKey)
+		opts := []salamoonder.Option{salamoonder.WithDefaultTimeout(*timeout)}
+		if u := firstNonEmpty(*baseURL, cfg.BaseURL); u != "" {
+			opts = append(opts, salamoonder.WithBaseURL(u))
+		}
+		c.client, err = salamoonder.New(apiKey, opts...)
+		if err != nil {
+			fmt.Fprintf(stderr, "salamoonder: %v (set -key or $%s)\n", err, envAPIKey)
+			return exitUsage
+		}
+	}
+
+	var err error
+	switch cmd {
+	case "balance":
+		err = c.balance(ctx, cmdArgs)
+	case "types":
+		err = c.types(cmdArgs)
+	case "create":
+		err = c.create(ctx, cmdArgs)
+	case "get":
+		err = c.get(ctx, cmdArgs)
+	case "wait":
+		err = c.wait(ctx, cmdArgs)
+	case "solve":
+		err = c.solve(ctx, cmdArgs)
+	default:
+		fmt.Fprintf(stderr, "salamoonder: unknown command %q\n", cmd)
+		global.Usage()
+		return exitUsage
+	}
+	if err != nil {
+		if !errors.Is(err, errUsage) {
+			fmt.Fprintf(stderr, "salamoonder: %v\n", err)
+		}
+		return exitCode(err)
+	}
+	return exitOK
+}
+
+// exitCode maps library errors to the documented exit codes.
+func exitCode(err error) int {
+	var apiErr *salamoonder.APIError
+	switch {
+	case errors.Is(err, errUsage):
+		return exitUsage
+	case errors.Is(err, salamoonder.ErrInvalidOptions), errors.Is(err, salamoonder.ErrUnsupportedTaskOptionsType):
+		return exitInvalidOptions
+	case errors.Is(err, salamoonder.ErrPollTimeout), errors.Is(err, context.DeadlineExceeded):
+		return exitTimeout
+	case errors.Is(err, salamoonder.ErrTaskFailed), errors.Is(err, salamoonder.ErrUnexpectedStatus):
+		return exitTaskFailed
+	case errors.Is(err, salamoonder.ErrTransport), isTransportError(err):
+		return exitTransport
+	case errors.As(err, &apiErr):
+		return exitAPI
+	default:
+		return exitError
+	}
+}
+
+// isTransportError reports whether err comes from the network rather than
+// from the API, e.g. an unreachable base URL.
+func isTransportError(err error) bool {
+	var (
+		urlErr *url.Error
+		opErr  *net.OpError
+		netErr net.Error
+	)
+	return errors.As(err, &urlErr) || errors.As(err, &opErr) || errors.As(err, &netErr) ||
+		errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+func (c *cli) balance(ctx context.Context, args []string) error {
+	fs := c.flags("balance", "")
+	if _, err := c.parse(fs, args, 0); err != nil {
+		return err
+	}
+
+	result, err := c.client.Balance(ctx)
+	if err != nil {
+		return err
+	}
+	if c.json {
+		return c.printJSON(result)
+	}
+	fmt.Fprintf(c.stdout, "Wallet: %s\n", result.Wallet)
+	return nil
+}
+
+func (c *cli) types(args []string) error {
+	fs := c.flags("types", "")
+	if _, err := c.parse(fs, args, 0); err != nil {
+		return err
+	}
+
+	names := salamoonder.TaskTypes()
+	if c.json {
+		return c.printJSON(names)
+	}
+	for _, name := range names {
+		fmt.Fprintln(c.stdout, name)
+	}
+	return nil
+}
+
+func (c *cli) create(ctx context.Context, args []string) error {
+	fs := c.flags("create", "<type>")
+	opts := fs.String("json", "{}", "task options as JSON, @file or - for stdin")
+	pos, err := c.parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+
+	options, err := c.options(pos[0], *opts)
+	if err != nil {
+		return err
+	}
+	result, err := c.client.CreateTask(ctx, options)
+	if err != nil {
+		return err
+	}
+	if c.json {
+		return c.printJSON(result)
+	}
+	fmt.Fprintf(c.stdout, "Task ID: %s\n", result.TaskId)
+	return nil
+}
+
+func (c *cli) get(ctx context.Context, args []string) error {
+	fs := c.flags("get", "<taskId>")
+	pos, err := c.parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+
+	result, err := c.client.Task(ctx, pos[0])
+	if err != nil {
+		return err
+	}
+	return c.printResult(result)
+}
+
+func (c *cli) wait(ctx context.Context, args []string) error {
+	fs := c.flags("wait", "<taskId>")
+	poll := pollFlags(fs)
+	pos, err := c.parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+
+	result, err := c.client.WaitForResult(ctx, pos[0], poll()...)
+	if err != nil {
+		return err
+	}
+	return c.printResult(result)
+}
+
+func (c *cli) solve(ctx context.Context, args []string) error {
+	fs := c.flags("solve", "<type>")
+	opts := fs.String("json", "{}", "task options as JSON, @file or - for stdin")
+	poll := pollFlags(fs)
+	pos, err := c.parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+
+	options, err := c.options(pos[0], *opts)
+	if err != nil {
+		return err
+	}
+	created, err := c.client.CreateTask(ctx, options)
+	if err != nil {
+		return err
+	}
+	if !c.json {
+		fmt.Fprintf(c.stderr, "Task ID: %s\n", created.TaskId)
+	}
+	result, err := c.client.WaitForResult(ctx, created.TaskId, poll()...)
+	if err != nil {
+		return err
+	}
+	return c.printResult(result)
+}
+
+func pollFlags(fs *flag.FlagSet) func() []salamoonder.PollOption {
+	interval := fs.Duration("interval", 2*time.Second, "poll interval")
+	maxWait := fs.Duration("max-wait", 5*time.Minute, "give up after this long; 0 waits forever")
+	return func() []salamoonder.PollOption {
+		return []salamoonder.PollOption{
+			salamoonder.WithPollInterval(*interval),
+			salamoonder.WithMaxWait(*maxWait),
+		}
+	}
+}
+
+func (c *cli) flags(name, positional string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(c.stderr, "usage: salamoonder %s [flags] %s\n", name, positional)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parse parses flags interspersed with exactly n positional arguments.
+func (c *cli) parse(fs *flag.FlagSet, args []string, n int) ([]string, error) {
+	var pos []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, errUsage
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			break
+		}
+		pos = append(pos, args[0])
+		args = args[1:]
+	}
+	if len(pos) != n {
+		fs.Usage()
+		return nil, errUsage
+	}
+	return pos, nil
+}
+
+// options decodes the -json value for the task type.
+func (c *cli) options(taskType, value string) (any, error) {
+	var data []byte
+	var err error
+	switch {
+	case value == "-":
+		data, err = io.ReadAll(c.stdin)
+	case strings.HasPrefix(value, "@"):
+		data, err = os.ReadFile(value[1:])
+	default:
+		data = []byte(value)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read options: %w", err)
+	}
+	return salamoonder.DecodeTaskOptions(taskType, data)
+}
+
+func (c *cli) printResult(result *salamoonder.TaskResultRaw) error {
+	if c.json {
+		return c.printJSON(result)
+	}
+	fmt.Fprintf(c.stdout, "Status: %s\n", result.Status)
+	if len(result.Solution) > 0 && string(result.Solution) != "null" {
+		solution, err := json.MarshalIndent(result.Solution, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(c.stdout, "Solution:\n%s\n", solution)
+	}
+	return nil
+}
+
+func (c *cli) printJSON(v any) error {
+	enc := json.NewEncoder(c.stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
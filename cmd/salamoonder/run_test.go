@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			APIKey string `json:"api_key"`
+			TaskId string `json:"taskId"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		w.Header().Set("Content-Type", "application/json")
+		if req.APIKey != "test-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error_code":1,"error_description":"invalid api key"}`))
+			return
+		}
+		switch {
+		case r.URL.Path == "/getBalance":
+			w.Write([]byte(`{"error_code":0,"error_description":"","wallet":"499.81070"}`))
+		case r.URL.Path == "/createTask":
+			w.Write([]byte(`{"error_code":0,"error_description":"","taskId":"task-1"}`))
+		case r.URL.Path == "/getTaskResult" && req.TaskId == "pending":
+			w.Write([]byte(`{"errorId":0,"status":"pending","solution":null}`))
+		case r.URL.Path == "/getTaskResult" && req.TaskId == "failed":
+			w.Write([]byte(`{"errorId":1,"status":"failed","solution":null}`))
+		case r.URL.Path == "/getTaskResult":
+			w.Write([]byte(`{"errorId":0,"status":"ready","solution":{"user-agent":"UA"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func runTest(t *testing.T, env map[string]string, args ...string) (int, string, string) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	getenv := func(k string) string { return env[k] }
+	code := run(context.Background(), args, strings.NewReader(""), &stdout, &stderr, getenv)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestRun(t *testing.T) {
+	ts := newTestServer(t)
+	env := map[string]string{envAPIKey: "test-key"}
+
+	tests := []struct {
+		name       string
+		env        map[string]string
+		args       []string
+		wantCode   int
+		wantStdout string
+	}{
+		{"balance", env, []string{"balance"}, exitOK, "Wallet: 499.81070"},
+		{"balance json", env, []string{"-o", "json", "balance"}, exitOK, `"wallet": "499.81070"`},
+		{"types", nil, []string{"types"}, exitOK, "KasadaCaptchaSolver"},
+		{"create", env, []string{"create", "KasadaCaptchaSolver", "-json", `{"pjs":"https://example.com/p.js"}`}, exitOK, "Task ID: task-1"},
+		{"create invalid", env, []string{"create", "KasadaCaptchaSolver"}, exitInvalidOptions, ""},
+		{"create unknown type", env, []string{"create", "NoSuchSolver"}, exitInvalidOptions, ""},
+		{"get", env, []string{"get", "task-1"}, exitOK, "Status: ready"},
+		{"get failed", env, []string{"get", "failed"}, exitAPI, ""},
+		{"wait failed", env, []string{"wait", "failed"}, exitTaskFailed, ""},
+		{"wait timeout", env, []string{"wait", "-interval", "1ms", "-max-wait", "20ms", "pending"}, exitTimeout, ""},
+		{"solve", env, []string{"solve", "-json", `{"pjs":"x"}`, "KasadaCaptchaSolver", "-interval", "1ms"}, exitOK, `"user-agent": "UA"`},
+		{"bad key", map[string]string{envAPIKey: "other"}, []string{"balance"}, exitAPI, ""},
+		{"no key", map[string]string{"HOME": t.TempDir()}, []string{"balance"}, exitUsage, ""},
+		{"unknown command", env, []string{"frobnicate"}, exitUsage, ""},
+		{"missing arg", env, []string{"get"}, exitUsage, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := append([]string{"-base-url", ts.URL}, tt.args...)
+			code, stdout, stderr := runTest(t, tt.env, args...)
+			if code != tt.wantCode {
+				t.Fatalf("run() = %d, want %d; stderr = %s", code, tt.wantCode, stderr)
+			}
+			if !strings.Contains(stdout, tt.wantStdout) {
+				t.Errorf("stdout = %q, want it to contain %q", stdout, tt.wantStdout)
+			}
+		})
+	}
+}
+
+func TestRun_Unreachable(t *testing.T) {
+	ts := httptest.NewServer(http.NotFoundHandler())
+	url := ts.URL
+	ts.Close()
+
+	env := map[string]string{envAPIKey: "test-key"}
+	for _, args := range [][]string{{"balance"}, {"get", "task-1"}} {
+		code, _, stderr := runTest(t, env, append([]string{"-base-url", url}, args...)...)
+		if code != exitTransport {
+			t.Errorf("run(%v) = %d, want %d; stderr = %s", args, code, exitTransport, stderr)
+		}
+	}
+}
+
+func TestRun_ConfigFile(t *testing.T) {
+	ts := newTestServer(t)
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "salamoonder", "config.json")
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	cfg := `{"api_key":"test-key","base_url":"` + ts.URL + `"}`
+	if err := os.WriteFile(path, []byte(cfg), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	code, stdout, stderr := runTest(t, map[string]string{"XDG_CONFIG_HOME": dir}, "balance")
+	if code != exitOK {
+		t.Fatalf("run() = %d, want %d; stderr = %s", code, exitOK, stderr)
+	}
+	if !strings.Contains(stdout, "499.81070") {
+		t.Errorf("stdout = %q, want wallet", stdout)
+	}
+}
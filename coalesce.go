@@ -0,0 +1,209 @@
+package salamoonder
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+type (
+	// CoalesceOption configures request coalescing enabled by WithCoalescing.
+	CoalesceOption func(*coalesceConfig)
+
+	// CoalesceKeyFunc returns the key under which concurrent Solve calls for
+	// options share one task. Calls with an empty key are not coalesced.
+	CoalesceKeyFunc func(options any) (string, error)
+
+	coalesceConfig struct {
+		keys map[string]CoalesceKeyFunc
+	}
+
+	// flightGroup tracks the Solve calls currently running per key.
+	flightGroup struct {
+		coalesceConfig
+
+		mu      sync.Mutex
+		flights map[string]*flight
+	}
+
+	// flight is one shared Solve. It runs on its own context, which is
+	// canceled once every caller waiting for it has given up.
+	flight struct {
+		ctx     context.Context
+		cancel  context.CancelFunc
+		done    chan struct{}
+		waiters int
+
+		result any
+		err    error
+	}
+)
+
+// WithCoalescing makes concurrent Solve calls with identical options share
+// one task: the first call creates and polls it, and every call waiting at
+// the time receives the same result or error. Calls are matched by task
+// type, solution type and OptionsHash unless WithCoalesceKey says otherwise.
+// The poll options of the first call apply to the shared task. Each call gets
+// its own copy of the solution, so callers may modify it.
+func WithCoalescing(opts ...CoalesceOption) Option {
+	return func(c *client) {
+		var cfg coalesceConfig
+		for _, opt := range opts {
+			if opt != nil {
+				opt(&cfg)
+			}
+		}
+		c.flights = &flightGroup{
+			coalesceConfig: cfg,
+			flights:        make(map[string]*flight),
+		}
+	}
+}
+
+// WithCoalesceKey sets how Solve calls of taskType are matched, e.g. to
+// ignore a field that does not affect the solution, or to return "" and
+// never coalesce the type.
+func WithCoalesceKey(taskType string, key CoalesceKeyFunc) CoalesceOption {
+	return func(cfg *coalesceConfig) {
+		if cfg.keys == nil {
+			cfg.keys = make(map[string]CoalesceKeyFunc)
+		}
+		cfg.keys[taskType] = key
+	}
+}
+
+// solveCoalesced runs solve, sharing it with concurrent calls for the same
+// options when coalescing is enabled.
+func solveCoalesced[TS TaskSolution](c *Client, ctx context.Context, options any, solve func(context.Context) (*TaskResult[TS], error)) (*TaskResult[TS], error) {
+	g := c.flights
+	if g == nil {
+		return solve(ctx)
+	}
+	key, err := g.key(options)
+	if err != nil {
+		return nil, err
+	}
+	if key == "" {
+		return solve(ctx)
+	}
+	key += "/" + reflect.TypeFor[TS]().String()
+
+	f, first := g.join(ctx, key)
+	if first {
+		go func() {
+			var (
+				result *TaskResult[TS]
+				err    error
+			)
+			// A panic would otherwise crash the process and leave every
+			// waiter blocked; it is reported to them as an error instead.
+			defer func() {
+				if r := recover(); r != nil {
+					result, err = nil, fmt.Errorf("coalesced solve panicked: %v", r)
+				}
+				g.finish(key, f, result, err)
+			}()
+			result, err = solve(f.ctx)
+		}()
+	}
+
+	select {
+	case <-f.done:
+	case <-ctx.Done():
+		g.leave(key, f)
+		return nil, fmt.Errorf("wait for coalesced solve: %w", ctx.Err())
+	}
+	if f.err != nil {
+		return nil, f.err
+	}
+	result, err := copyResult(f.result.(*TaskResult[TS]))
+	if err != nil {
+		return nil, err
+	}
+	result.Coalesced = !first
+	return result, nil
+}
+
+// copyResult returns a deep copy of r. The solution is copied by encoding and
+// decoding it, so that maps such as Extra are not shared between callers.
+func copyResult[TS TaskSolution](r *TaskResult[TS]) (*TaskResult[TS], error) {
+	out := *r
+	data, err := json.Marshal(r.Solution)
+	if err != nil {
+		return nil, fmt.Errorf("copy coalesced solution: %w", err)
+	}
+	var solution TS
+	if err := json.Unmarshal(data, &solution); err != nil {
+		return nil, fmt.Errorf("copy coalesced solution: %w", err)
+	}
+	out.Solution = solution
+	if r.HTTPTimings != nil {
+		t := *r.HTTPTimings
+		out.HTTPTimings = &t
+	}
+	if r.TaskTimings != nil {
+		t := *r.TaskTimings
+		out.TaskTimings = &t
+	}
+	return &out, nil
+}
+
+func (g *flightGroup) key(options any) (string, error) {
+	taskType, ok := TaskTypeOf(options)
+	if !ok {
+		return "", &MethodError{OptionsValue: options}
+	}
+	if key, ok := g.keys[taskType]; ok {
+		k, err := key(options)
+		if err != nil || k == "" {
+			return "", err
+		}
+		return taskType + "/" + k, nil
+	}
+	return cacheKey(options)
+}
+
+// join returns the flight for key, starting one on ctx if none is running.
+// first reports whether the caller started it.
+func (g *flightGroup) join(ctx context.Context, key string) (f *flight, first bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if f, ok := g.flights[key]; ok {
+		f.waiters++
+		return f, false
+	}
+	f = &flight{done: make(chan struct{}), waiters: 1}
+	f.ctx, f.cancel = context.WithCancel(context.WithoutCancel(ctx))
+	g.flights[key] = f
+	return f, true
+}
+
+// leave is called by a caller that stopped waiting. The last one to leave
+// cancels the flight, so that later calls start a new one.
+func (g *flightGroup) leave(key string, f *flight) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if f.waiters--; f.waiters > 0 {
+		return
+	}
+	if g.flights[key] == f {
+		delete(g.flights, key)
+	}
+	f.cancel()
+}
+
+func (g *flightGroup) finish(key string, f *flight, result any, err error) {
+	g.mu.Lock()
+	if g.flights[key] == f {
+		delete(g.flights, key)
+	}
+	g.mu.Unlock()
+
+	f.result, f.err = result, err
+	close(f.done)
+	f.cancel()
+}
@@ -0,0 +1,240 @@
+package salamoonder
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newCoalesceTestClient reports tasks as pending until release is closed.
+func newCoalesceTestClient(t *testing.T, opts ...Option) (*Client, *atomic.Int32, chan struct{}) {
+	t.Helper()
+	api := &fakeAPI{Release: make(chan struct{}), Solution: `{"user-agent":"UA"}`}
+	c, closeFn := newTestClient(t, api.ServeHTTP, opts...)
+	t.Cleanup(closeFn)
+	return c, &api.creates, api.Release
+}
+
+func solveConcurrently(c *Client, n int, options func(i int) KasadaStandardOptions) ([]*TaskResult[KasadaStandardSolution], []error) {
+	results := make([]*TaskResult[KasadaStandardSolution], n)
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], errs[i] = Solve[KasadaStandardSolution](c, context.Background(), options(i), WithPollInterval(time.Millisecond))
+		}()
+	}
+	wg.Wait()
+	return results, errs
+}
+
+func sameOptions(int) KasadaStandardOptions {
+	return KasadaStandardOptions{Pjs: "https://example.com/p.js"}
+}
+
+func TestSolve_Coalescing(t *testing.T) {
+	c, creates, release := newCoalesceTestClient(t, WithCoalescing())
+	time.AfterFunc(50*time.Millisecond, func() { close(release) })
+
+	results, errs := solveConcurrently(c, 10, sameOptions)
+
+	coalesced := 0
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("Solve() #%d error: %v", i, err)
+		}
+		if results[i].Solution.UserAgent != "UA" {
+			t.Errorf("Solve() #%d solution = %+v, want the shared solution", i, results[i].Solution)
+		}
+		if results[i].Coalesced {
+			coalesced++
+		}
+	}
+	if n := creates.Load(); n != 1 {
+		t.Errorf("tasks created = %d, want 1", n)
+	}
+	if coalesced != 9 {
+		t.Errorf("coalesced results = %d, want 9", coalesced)
+	}
+}
+
+func TestSolve_CoalescingSharesErrors(t *testing.T) {
+	var creates atomic.Int32
+	h := func(w http.ResponseWriter, r *http.Request) {
+		creates.Add(1)
+		time.Sleep(50 * time.Millisecond)
+		w.WriteHeader(http.StatusPaymentRequired)
+		w.Write([]byte(`{"error_code":1,"error_description":"insufficient funds"}`))
+	}
+	c, closeFn := newTestClient(t, h, WithCoalescing())
+	defer closeFn()
+
+	_, errs := solveConcurrently(c, 5, sameOptions)
+	for i, err := range errs {
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) {
+			t.Errorf("Solve() #%d error = %v, want *APIError", i, err)
+		}
+	}
+	if n := creates.Load(); n != 1 {
+		t.Errorf("tasks created = %d, want 1", n)
+	}
+}
+
+func TestSolve_CoalescingCopiesSolution(t *testing.T) {
+	h := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == EndpointCreateTask {
+			time.Sleep(50 * time.Millisecond)
+			w.Write([]byte(`{"error_code":0,"taskId":"task-1"}`))
+			return
+		}
+		w.Write([]byte(`{"errorId":0,"status":"ready","solution":{"user-agent":"UA","extra":"x"}}`))
+	}
+	c, closeFn := newTestClient(t, h, WithCoalescing())
+	defer closeFn()
+
+	results, errs := solveConcurrently(c, 3, sameOptions)
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("Solve() #%d error: %v", i, err)
+		}
+	}
+	results[0].Solution.Extra["extra"] = json.RawMessage(`"changed"`)
+	for i, r := range results[1:] {
+		if got := string(r.Solution.Extra["extra"]); got != `"x"` {
+			t.Errorf("Solve() #%d Extra[extra] = %s, want \"x\" unaffected by another caller", i+1, got)
+		}
+	}
+}
+
+func TestSolve_CoalescingRecoversPanic(t *testing.T) {
+	c, _, _ := newCoalesceTestClient(t, WithCoalescing(), WithMiddleware(func(next Handler) Handler {
+		return func(ctx context.Context, call *Call) (*Response, error) {
+			if call.Endpoint == EndpointGetTaskResult {
+				time.Sleep(50 * time.Millisecond)
+				panic("boom")
+			}
+			return next(ctx, call)
+		}
+	}))
+
+	_, errs := solveConcurrently(c, 3, sameOptions)
+	for i, err := range errs {
+		if err == nil || !strings.Contains(err.Error(), "boom") {
+			t.Errorf("Solve() #%d error = %v, want the panic", i, err)
+		}
+	}
+}
+
+func TestSolve_CoalescingDisabled(t *testing.T) {
+	c, creates, release := newCoalesceTestClient(t)
+	close(release)
+
+	if _, errs := solveConcurrently(c, 3, sameOptions); errors.Join(errs...) != nil {
+		t.Fatalf("Solve() error: %v", errors.Join(errs...))
+	}
+	if n := creates.Load(); n != 3 {
+		t.Errorf("tasks created = %d, want 3", n)
+	}
+}
+
+func TestWithCoalesceKey(t *testing.T) {
+	byHost := func(options any) (string, error) {
+		return "example.com", nil
+	}
+	never := func(options any) (string, error) {
+		return "", nil
+	}
+	differentOptions := func(i int) KasadaStandardOptions {
+		return KasadaStandardOptions{Pjs: "https://example.com/p.js", CdOnly: i%2 == 0}
+	}
+
+	tests := []struct {
+		name        string
+		key         CoalesceKeyFunc
+		options     func(int) KasadaStandardOptions
+		wantCreates int32
+	}{
+		{"default key", nil, differentOptions, 2},
+		{"custom key", byHost, differentOptions, 1},
+		{"never", never, sameOptions, 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opt CoalesceOption
+			if tt.key != nil {
+				opt = WithCoalesceKey("KasadaCaptchaSolver", tt.key)
+			}
+			c, creates, release := newCoalesceTestClient(t, WithCoalescing(opt))
+			time.AfterFunc(50*time.Millisecond, func() { close(release) })
+
+			if _, errs := solveConcurrently(c, 4, tt.options); errors.Join(errs...) != nil {
+				t.Fatalf("Solve() error: %v", errors.Join(errs...))
+			}
+			if n := creates.Load(); n != tt.wantCreates {
+				t.Errorf("tasks created = %d, want %d", n, tt.wantCreates)
+			}
+		})
+	}
+}
+
+func TestSolve_CoalescingCancel(t *testing.T) {
+	c, creates, release := newCoalesceTestClient(t, WithCoalescing())
+
+	// A caller giving up does not fail the others.
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() {
+		_, err := Solve[KasadaStandardSolution](c, ctx, sameOptions(0), WithPollInterval(time.Millisecond))
+		errc <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	time.AfterFunc(40*time.Millisecond, cancel)
+	time.AfterFunc(80*time.Millisecond, func() { close(release) })
+
+	result, err := Solve[KasadaStandardSolution](c, context.Background(), sameOptions(0), WithPollInterval(time.Millisecond))
+	if err != nil {
+		t.Fatalf("Solve() error: %v", err)
+	}
+	if !result.Coalesced {
+		t.Error("Coalesced = false, want true")
+	}
+	if err := <-errc; !errors.Is(err, context.Canceled) {
+		t.Errorf("canceled Solve() error = %v, want context.Canceled", err)
+	}
+	if n := creates.Load(); n != 1 {
+		t.Errorf("tasks created = %d, want 1", n)
+	}
+}
+
+func TestSolve_CoalescingAbandoned(t *testing.T) {
+	c, creates, release := newCoalesceTestClient(t, WithCoalescing())
+
+	// Once every caller gave up, the next call starts a new task.
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := Solve[KasadaStandardSolution](c, ctx, sameOptions(0), WithPollInterval(time.Millisecond)); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Solve() error = %v, want context.DeadlineExceeded", err)
+	}
+
+	close(release)
+	result, err := Solve[KasadaStandardSolution](c, context.Background(), sameOptions(0), WithPollInterval(time.Millisecond))
+	if err != nil {
+		t.Fatalf("Solve() error: %v", err)
+	}
+	if result.Coalesced {
+		t.Error("Coalesced = true, want a new task")
+	}
+	if n := creates.Load(); n != 2 {
+		t.Errorf("tasks created = %d, want 2", n)
+	}
+}
@@ -0,0 +1,16 @@
+package salamoonder
+
+import "context"
+
+type taskTypeKey struct{}
+
+// withTaskType records the Salamoonder task type a call is made for, so that
+// per-type limits and instrumentation also apply to /getTaskResult calls.
+func withTaskType(ctx context.Context, taskType string) context.Context {
+	return context.WithValue(ctx, taskTypeKey{}, taskType)
+}
+
+func taskTypeFromContext(ctx context.Context) string {
+	taskType, _ := ctx.Value(taskTypeKey{}).(string)
+	return taskType
+}
@@ -3,7 +3,10 @@ package salamoonder
 import (
 	"errors"
 	"fmt"
+	"net/http"
 	"reflect"
+	"strings"
+	"time"
 )
 
 var (
@@ -16,22 +19,88 @@ var (
 	*/
 	ErrUnsupportedTaskOptionsType = errors.New("unsupported task options type")
 
+	/*
+		ErrPollTimeout, ErrTaskFailed, ErrUnexpectedStatus and ErrTransport
+		classify failures returned from WaitForResult and Solve. Use errors.Is
+		for checking, and errors.As(*PollError) to get details.
+	*/
+	ErrPollTimeout      = errors.New("timed out waiting for task")
+	ErrTaskFailed       = errors.New("task failed")
+	ErrUnexpectedStatus = errors.New("unexpected task status")
+	ErrTransport        = errors.New("transport failure")
+
+	// ErrLimitExceeded is returned in LimitFailFast mode when a client-side
+	// rate or concurrency limit does not admit a call.
+	ErrLimitExceeded = errors.New("client-side limit exceeded")
+
+	/*
+		ErrInvalidAPIKey, ErrInsufficientBalance, ErrTaskNotFound and
+		ErrRateLimited classify *APIError values. Use errors.Is for checking,
+		and errors.As(*APIError) to get details.
+	*/
+	ErrInvalidAPIKey       = errors.New("invalid api key")
+	ErrInsufficientBalance = errors.New("insufficient balance")
+	ErrTaskNotFound        = errors.New("task not found")
+	ErrRateLimited         = errors.New("rate limited")
+
+	/*
+		ErrInvalidOptions is returned from CreateTask and Solve if the options
+		fail validation. Use errors.Is for checking,
+		and errors.As(*ValidationError) to get the invalid fields.
+	*/
+	ErrInvalidOptions = errors.New("invalid task options")
+
+	// ErrBudgetExceeded is returned from CreateTask and Solve, before any
+	// HTTP call, when the task would exceed a spending cap set WithBudget.
+	ErrBudgetExceeded = errors.New("budget exceeded")
+
+	// ErrPoolClosed is returned from Pool.Submit after Shutdown, and reported
+	// for queued jobs dropped by a Shutdown that ran out of time.
+	ErrPoolClosed = errors.New("pool closed")
+
+	// ErrUnknownFields is returned with WithStrictDecoding, and from
+	// TaskResultRaw.DecodeSolutionStrict, when a solution has fields its type
+	// does not declare.
+	ErrUnknownFields = errors.New("unknown solution fields")
+
 	_ error = (*APIError)(nil)
 	_ error = (*MethodError)(nil)
+	_ error = (*PollError)(nil)
+	_ error = (*ValidationError)(nil)
 )
 
-var allowedTaskTypes = make([]reflect.Type, 0)
-
 type (
 	APIError struct {
 		TaskId     string
 		StatusCode int
 		Msg        string
+		// ErrorCode is the error_code (or errorId) reported in the body.
+		ErrorCode int
+		// Endpoint is the path of the failed call, e.g. EndpointCreateTask.
+		Endpoint string
+		// Body is the raw response body, truncated to maxErrorBodyLen bytes.
+		Body   string
+		Header http.Header
+		// RetryAfter is the wait requested by the Retry-After header, if any.
+		RetryAfter time.Duration
+		// Status is the task status reported along with a /getTaskResult
+		// errorId, if any.
+		Status TaskStatus
 	}
 
 	MethodError struct {
 		OptionsValue any
 	}
+
+	PollError struct {
+		TaskId string
+		// Kind is one of ErrPollTimeout, ErrTaskFailed, ErrUnexpectedStatus
+		// or ErrTransport.
+		Kind error
+		// Status is the last status reported by the API, if any.
+		Status TaskStatus
+		Err    error
+	}
 )
 
 func (a *APIError) Error() string {
@@ -51,20 +120,92 @@ func (a *APIError) Error() string {
 	}
 }
 
+/*
+Is allows using errors.Is(err, ErrInvalidAPIKey), errors.Is(err, ErrInsufficientBalance),
+errors.Is(err, ErrTaskNotFound) and errors.Is(err, ErrRateLimited).
+*/
+func (a *APIError) Is(target error) bool {
+	kind := a.kind()
+	return kind != nil && target == kind
+}
+
+// Temporary reports whether the failure is expected to clear by itself:
+// rate limiting and server-side (5xx) errors.
+func (a *APIError) Temporary() bool {
+	return a.StatusCode == http.StatusTooManyRequests || a.StatusCode >= 500
+}
+
+// Retryable reports whether repeating the same request may succeed. Besides
+// temporary failures this covers in-band failures reported with HTTP 200,
+// unless they are caused by the key or the balance, or report a task that
+// has already failed.
+func (a *APIError) Retryable() bool {
+	if a.Temporary() {
+		return true
+	}
+	if a.StatusCode != http.StatusOK {
+		return false
+	}
+	if a.Endpoint == EndpointGetTaskResult && a.Status.IsTerminal() {
+		return false
+	}
+	kind := a.kind()
+	return kind != ErrInvalidAPIKey && kind != ErrInsufficientBalance
+}
+
+// errorDescriptionKinds classifies 200 and 400 responses, whose error_code
+// is the generic 1, by their exact error_description. Only descriptions the
+// API is known to send are listed; any other wording leaves the error
+// unclassified rather than guessing from a substring.
+var errorDescriptionKinds = map[string]error{
+	"insufficient funds": ErrInsufficientBalance,
+	"task not found":     ErrTaskNotFound,
+}
+
+// kind maps the failure to one of the sentinel errors. The status code is
+// authoritative; 200 and 400 responses, which the API uses for all kinds of
+// failures, fall back to errorDescriptionKinds.
+func (a *APIError) kind() error {
+	switch a.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return ErrInvalidAPIKey
+	case http.StatusPaymentRequired:
+		return ErrInsufficientBalance
+	case http.StatusNotFound:
+		if a.Endpoint == EndpointGetTaskResult {
+			return ErrTaskNotFound
+		}
+		return nil
+	case http.StatusTooManyRequests:
+		return ErrRateLimited
+	case http.StatusOK, http.StatusBadRequest:
+		return errorDescriptionKinds[strings.ToLower(strings.TrimSpace(a.Msg))]
+	default:
+		return nil
+	}
+}
+
 func (m *MethodError) Error() string {
-	actualType := reflect.TypeOf(m.OptionsValue)
-	allowed := make([]string, len(allowedTaskTypes))
-	for i, t := range allowedTaskTypes {
-		allowed[i] = t.Name()
+	registered := registry.registered()
+	allowed := make([]string, len(registered))
+	for i, t := range registered {
+		allowed[i] = fmt.Sprintf("%s (%s)", t.optionsType.Name(), t.name)
 	}
 
 	return fmt.Sprintf(
 		"invalid Task type %v; allowed: %v",
-		actualType.Name(),
+		typeName(m.OptionsValue),
 		allowed,
 	)
 }
 
+func typeName(v any) string {
+	if v == nil {
+		return "<nil>"
+	}
+	return reflect.TypeOf(v).Name()
+}
+
 /*
 Is allows using errors.Is(err, ErrUnsupportedTaskOptionsType).
 To get details (actual type), use errors.As(*MethodError).
@@ -72,3 +213,26 @@ To get details (actual type), use errors.As(*MethodError).
 func (m *MethodError) Is(target error) bool {
 	return target == ErrUnsupportedTaskOptionsType
 }
+
+func (p *PollError) Error() string {
+	switch {
+	case p.Err != nil:
+		return fmt.Sprintf("wait for task [%s]: %v: %v", p.TaskId, p.Kind, p.Err)
+	case p.Status != "":
+		return fmt.Sprintf("wait for task [%s]: %v: status %q", p.TaskId, p.Kind, p.Status)
+	default:
+		return fmt.Sprintf("wait for task [%s]: %v", p.TaskId, p.Kind)
+	}
+}
+
+/*
+Is allows using errors.Is(err, ErrPollTimeout), errors.Is(err, ErrTaskFailed)
+and errors.Is(err, ErrTransport). The underlying error is available via Unwrap.
+*/
+func (p *PollError) Is(target error) bool {
+	return target == p.Kind
+}
+
+func (p *PollError) Unwrap() error {
+	return p.Err
+}
@@ -0,0 +1,123 @@
+package salamoonder
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestAPIError_Classification(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       *APIError
+		want      error
+		retryable bool
+		temporary bool
+	}{
+		{"401", &APIError{StatusCode: 401}, ErrInvalidAPIKey, false, false},
+		{"402", &APIError{StatusCode: 402}, ErrInsufficientBalance, false, false},
+		{"404 task", &APIError{StatusCode: 404, Endpoint: EndpointGetTaskResult}, ErrTaskNotFound, false, false},
+		{"429", &APIError{StatusCode: 429}, ErrRateLimited, true, true},
+		{"503", &APIError{StatusCode: 503}, nil, true, true},
+		{"200 funds", &APIError{StatusCode: 200, ErrorCode: 1, Msg: "insufficient funds"}, ErrInsufficientBalance, false, false},
+		{"200 funds case", &APIError{StatusCode: 200, ErrorCode: 1, Msg: " Insufficient Funds "}, ErrInsufficientBalance, false, false},
+		{"200 other", &APIError{StatusCode: 200, ErrorCode: 1, Msg: "solver unavailable"}, nil, true, false},
+		{"200 reworded", &APIError{StatusCode: 200, ErrorCode: 1, Msg: "insufficient funds for task"}, nil, true, false},
+		{"200 failed task", &APIError{StatusCode: 200, ErrorCode: 1, Endpoint: EndpointGetTaskResult, Status: TaskStatusFailed}, nil, false, false},
+		{"200 task without status", &APIError{StatusCode: 200, ErrorCode: 1, Endpoint: EndpointGetTaskResult}, nil, true, false},
+		{"400 task", &APIError{StatusCode: 400, ErrorCode: 1, Msg: "task not found"}, ErrTaskNotFound, false, false},
+		{"400 invalid", &APIError{StatusCode: 400, ErrorCode: 1, Msg: "invalid taskId format"}, nil, false, false},
+		{"message on 401", &APIError{StatusCode: 401, ErrorCode: 1, Msg: "task not found"}, ErrInvalidAPIKey, false, false},
+	}
+
+	sentinels := []error{ErrInvalidAPIKey, ErrInsufficientBalance, ErrTaskNotFound, ErrRateLimited}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, s := range sentinels {
+				if got := errors.Is(tt.err, s); got != (s == tt.want) {
+					t.Errorf("errors.Is(err, %v) = %v, want %v", s, got, s == tt.want)
+				}
+			}
+			if got := tt.err.Retryable(); got != tt.retryable {
+				t.Errorf("Retryable() = %v, want %v", got, tt.retryable)
+			}
+			if got := tt.err.Temporary(); got != tt.temporary {
+				t.Errorf("Temporary() = %v, want %v", got, tt.temporary)
+			}
+		})
+	}
+}
+
+func TestAPIError_FromStatus(t *testing.T) {
+	tests := []struct {
+		status int
+		body   string
+		want   error
+	}{
+		{http.StatusUnauthorized, `{"error_code":1,"error_description":"bad key"}`, ErrInvalidAPIKey},
+		{http.StatusPaymentRequired, `{"error_code":1,"error_description":"no money"}`, ErrInsufficientBalance},
+		{http.StatusTooManyRequests, `slow down`, ErrRateLimited},
+	}
+
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			h := func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Request-Id", "req-1")
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}
+
+			c, closeFn := newTestClient(t, h)
+			defer closeFn()
+
+			_, err := c.CreateTask(context.Background(), KasadaStandardOptions{Pjs: "x"})
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("errors.Is(err, %v) = false, want true; err = %v", tt.want, err)
+			}
+
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("CreateTask() error type = %T, want *APIError", err)
+			}
+			if apiErr.StatusCode != tt.status || apiErr.Endpoint != EndpointCreateTask {
+				t.Errorf("APIError = %+v", apiErr)
+			}
+			if apiErr.Header.Get("X-Request-Id") != "req-1" {
+				t.Errorf("APIError.Header missing X-Request-Id")
+			}
+			if apiErr.Body != tt.body {
+				t.Errorf("APIError.Body = %q, want %q", apiErr.Body, tt.body)
+			}
+		})
+	}
+}
+
+func TestAPIError_ErrorCodeAndTruncatedBody(t *testing.T) {
+	long := strings.Repeat("x", 2*maxErrorBodyLen)
+	h := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"error_code":7,"error_description":"solver unavailable","pad":"` + long + `"}`))
+	}
+
+	c, closeFn := newTestClient(t, h)
+	defer closeFn()
+
+	_, err := c.Balance(context.Background())
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("Balance() error type = %T, want *APIError", err)
+	}
+	if apiErr.ErrorCode != 7 {
+		t.Errorf("APIError.ErrorCode = %d, want 7", apiErr.ErrorCode)
+	}
+	if len(apiErr.Body) != maxErrorBodyLen {
+		t.Errorf("len(APIError.Body) = %d, want %d", len(apiErr.Body), maxErrorBodyLen)
+	}
+	if !apiErr.Retryable() {
+		t.Error("APIError.Retryable() = false, want true")
+	}
+}
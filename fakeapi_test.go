@@ -0,0 +1,103 @@
+package salamoonder
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// fakeAPI is a fake of the task endpoints for the tests in this package,
+// which cannot import salamoontest. The zero value creates task-1, task-2,
+// ... and reports each ready at its first poll, with the task id as the
+// solution's user agent. Tasks whose id starts with "failed" fail, and a
+// task named "pending" never finishes.
+type fakeAPI struct {
+	// Pending is how many polls of each task report it pending.
+	Pending int
+	// Release, if set, keeps every task pending until it is closed.
+	Release chan struct{}
+	// Solution, if set, is the JSON solution of every ready task.
+	Solution string
+	// TaskId, if set, names the task created for the options' pjs.
+	TaskId func(pjs string) string
+	// OnCreate, if set, is called with the pjs of every task before it is
+	// created.
+	OnCreate func(pjs string)
+	// Wallet, if set, returns the balance reported by /getBalance;
+	// otherwise it is 1.00.
+	Wallet func() string
+
+	creates atomic.Int32
+	polls   atomic.Int32
+
+	mu        sync.Mutex
+	taskPolls map[string]int
+}
+
+func (f *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Task struct {
+			Pjs string `json:"pjs"`
+		} `json:"task"`
+		TaskId string `json:"taskId"`
+	}
+	json.NewDecoder(r.Body).Decode(&req)
+	w.Header().Set("Content-Type", "application/json")
+
+	switch r.URL.Path {
+	case EndpointCreateTask:
+		if f.OnCreate != nil {
+			f.OnCreate(req.Task.Pjs)
+		}
+		n := f.creates.Add(1)
+		id := fmt.Sprintf("task-%d", n)
+		if f.TaskId != nil {
+			id = f.TaskId(req.Task.Pjs)
+		}
+		fmt.Fprintf(w, `{"error_code":0,"taskId":%q}`, id)
+	case EndpointGetTaskResult:
+		f.polls.Add(1)
+		switch {
+		case strings.HasPrefix(req.TaskId, "failed"):
+			w.Write([]byte(`{"errorId":1,"status":"failed","solution":null}`))
+		case req.TaskId == "pending" || f.pending(req.TaskId):
+			w.Write([]byte(`{"errorId":0,"status":"pending","solution":null}`))
+		case f.Solution != "":
+			fmt.Fprintf(w, `{"errorId":0,"status":"ready","solution":%s}`, f.Solution)
+		default:
+			fmt.Fprintf(w, `{"errorId":0,"status":"ready","solution":{"user-agent":%q}}`, req.TaskId)
+		}
+	case EndpointGetBalance:
+		wallet := "1.00"
+		if f.Wallet != nil {
+			wallet = f.Wallet()
+		}
+		fmt.Fprintf(w, `{"error_code":0,"wallet":%q}`, wallet)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// pending counts a poll of taskId and reports whether the task is still
+// pending at it.
+func (f *fakeAPI) pending(taskId string) bool {
+	if f.Release != nil {
+		select {
+		case <-f.Release:
+		default:
+			return true
+		}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.taskPolls == nil {
+		f.taskPolls = make(map[string]int)
+	}
+	f.taskPolls[taskId]++
+	return f.taskPolls[taskId] <= f.Pending
+}
@@ -0,0 +1,274 @@
+package salamoonder
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// maxConcurrentPolls bounds the status checks the shared poller runs at once.
+const maxConcurrentPolls = 16
+
+type (
+	// Future is the pending solution of a task created by Submit.
+	Future[TS TaskSolution] struct {
+		done   chan struct{}
+		cancel context.CancelFunc
+
+		mu     sync.Mutex
+		taskId string
+
+		clock    *taskClock
+		solution TS
+		timings  *TaskTimings
+		err      error
+	}
+
+	// poller checks the status of every task submitted on a client from a
+	// single goroutine, which only runs while tasks are outstanding.
+	poller struct {
+		client *Client
+
+		mu      sync.Mutex
+		entries map[*pollEntry]struct{}
+		running bool
+		wake    chan struct{}
+		sem     chan struct{}
+	}
+
+	pollEntry struct {
+		ctx     context.Context
+		cancel  context.CancelFunc
+		stop    func() bool
+		taskId  string
+		cfg     pollConfig
+		next    time.Time
+		polling bool
+		attempt int
+		unknown int
+		finish  func(*TaskResultRaw, error)
+	}
+)
+
+// Submit creates a task for options in the background and returns a Future
+// for its solution. Unlike Solve it does not block: the task is created by a
+// short-lived goroutine and then polled by the client's shared poller, so
+// hundreds of tasks can be outstanding at once. Creation errors and
+// *PollError failures are reported by Result.
+func Submit[TS TaskSolution, TO TaskOptions](c *Client, ctx context.Context, options TO, opts ...PollOption) *Future[TS] {
+	ctx, clock := withTaskClock(ctx, time.Now())
+	ctx, cancel := context.WithCancel(withTaskType(ctx, getTaskTypeFromOptions(options)))
+	f := &Future[TS]{
+		done:   make(chan struct{}),
+		cancel: cancel,
+		clock:  clock,
+	}
+	cfg := newPollConfig(opts)
+
+	go func() {
+		created, err := createTaskGeneric(c, ctx, options)
+		if err != nil {
+			f.complete(nil, err, false)
+			return
+		}
+		f.mu.Lock()
+		f.taskId = created.TaskId
+		f.mu.Unlock()
+
+		c.poller.add(ctx, created.TaskId, cfg, func(result *TaskResultRaw, err error) {
+			f.complete(result, err, c.strictDecoding)
+		})
+	}()
+	return f
+}
+
+// Done is closed once the result is available.
+func (f *Future[TS]) Done() <-chan struct{} {
+	return f.done
+}
+
+// Result blocks until the task is solved, fails, or the future is canceled.
+func (f *Future[TS]) Result() (TS, error) {
+	<-f.done
+	return f.solution, f.err
+}
+
+// TaskID returns the task id, or "" while the task is still being created or
+// if creating it failed.
+func (f *Future[TS]) TaskID() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.taskId
+}
+
+// Timings returns the task's timing breakdown once Done is closed, and nil
+// before.
+func (f *Future[TS]) Timings() *TaskTimings {
+	select {
+	case <-f.done:
+		return f.timings
+	default:
+		return nil
+	}
+}
+
+// Cancel stops waiting for the task. Result then returns an error wrapping
+// context.Canceled. The task itself keeps running on the server.
+func (f *Future[TS]) Cancel() {
+	f.cancel()
+}
+
+func (f *Future[TS]) complete(result *TaskResultRaw, err error, strict bool) {
+	if err == nil {
+		if jsonErr := json.Unmarshal(result.Solution, &f.solution); jsonErr != nil {
+			err = fmt.Errorf("decode solution: %w", jsonErr)
+		} else if strict {
+			err = checkUnknownFields(f.solution)
+		}
+	}
+	f.err = err
+	f.timings = f.clock.timings()
+	close(f.done)
+	f.cancel()
+}
+
+func newPoller(c *Client) *poller {
+	return &poller{
+		client:  c,
+		entries: make(map[*pollEntry]struct{}),
+		wake:    make(chan struct{}, 1),
+		sem:     make(chan struct{}, maxConcurrentPolls),
+	}
+}
+
+// add starts polling taskId. finish is called exactly once, with the ready
+// result or the error that ended the wait.
+func (p *poller) add(ctx context.Context, taskId string, cfg pollConfig, finish func(*TaskResultRaw, error)) {
+	e := &pollEntry{
+		taskId: taskId,
+		cfg:    cfg,
+		next:   time.Now(),
+		finish: finish,
+	}
+	if cfg.maxWait > 0 {
+		e.ctx, e.cancel = context.WithTimeout(ctx, cfg.maxWait)
+	} else {
+		e.ctx, e.cancel = context.WithCancel(ctx)
+	}
+	e.stop = context.AfterFunc(e.ctx, p.signal)
+
+	p.mu.Lock()
+	p.entries[e] = struct{}{}
+	if !p.running {
+		p.running = true
+		go p.run()
+	}
+	p.mu.Unlock()
+	p.signal()
+}
+
+func (p *poller) signal() {
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (p *poller) run() {
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+
+	for {
+		p.mu.Lock()
+		if len(p.entries) == 0 {
+			p.running = false
+			p.mu.Unlock()
+			return
+		}
+		now := time.Now()
+		var due []*pollEntry
+		var next time.Time
+		for e := range p.entries {
+			switch {
+			case e.polling:
+			case e.ctx.Err() != nil || !e.next.After(now):
+				e.polling = true
+				due = append(due, e)
+			case next.IsZero() || e.next.Before(next):
+				next = e.next
+			}
+		}
+		p.mu.Unlock()
+
+		if len(due) > 0 {
+			p.check(due)
+			continue
+		}
+
+		// With every entry being polled there is nothing to time; the
+		// polls signal when they finish.
+		var timeout <-chan time.Time
+		if !next.IsZero() {
+			timer.Reset(time.Until(next))
+			timeout = timer.C
+		}
+		select {
+		case <-timeout:
+		case <-p.wake:
+			timer.Stop()
+		}
+	}
+}
+
+// check starts polling the due entries, at most maxConcurrentPolls at once
+// across the poller. It does not wait for them: each entry is rescheduled
+// when its own poll finishes, so a slow poll does not hold up the others.
+func (p *poller) check(due []*pollEntry) {
+	for _, e := range due {
+		p.sem <- struct{}{}
+		go func() {
+			defer func() { <-p.sem }()
+			p.checkOne(e)
+		}()
+	}
+}
+
+func (p *poller) checkOne(e *pollEntry) {
+	var (
+		result *TaskResultRaw
+		status TaskStatus
+		err    = e.ctx.Err()
+	)
+	ctx, span := p.client.startSpan(e.ctx, SpanPoll, slog.String(AttrTaskId, e.taskId), slog.Int(AttrAttempt, e.attempt+1))
+	if err == nil {
+		result, err = p.client.Task(ctx, e.taskId)
+		if result != nil {
+			status = result.Status
+		}
+	}
+
+	done, err := pollStep(e.ctx, e.taskId, status, err, &e.unknown, e.cfg.maxUnknown)
+	endPollSpan(span, status, err)
+	if !done {
+		p.mu.Lock()
+		e.next = time.Now().Add(e.cfg.delay(e.attempt))
+		e.polling = false
+		p.mu.Unlock()
+		e.attempt++
+		p.signal()
+		return
+	}
+
+	p.mu.Lock()
+	delete(p.entries, e)
+	p.mu.Unlock()
+	p.signal()
+
+	e.stop()
+	e.cancel()
+	e.finish(result, err)
+}
@@ -0,0 +1,182 @@
+package salamoonder
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var futureOptions = KasadaStandardOptions{Pjs: "https://example.com/p.js"}
+
+func TestSubmit_Result(t *testing.T) {
+	api := &fakeAPI{Pending: 2}
+	c, closeFn := newTestClient(t, api.ServeHTTP)
+	defer closeFn()
+
+	f := Submit[KasadaStandardSolution](c, context.Background(), futureOptions, WithPollInterval(time.Millisecond))
+	solution, err := f.Result()
+	if err != nil {
+		t.Fatalf("Result() error: %v", err)
+	}
+	if solution.UserAgent != "task-1" {
+		t.Errorf("UserAgent = %q, want task-1", solution.UserAgent)
+	}
+	if f.TaskID() != "task-1" {
+		t.Errorf("TaskID() = %q, want task-1", f.TaskID())
+	}
+	if n := api.polls.Load(); n != 3 {
+		t.Errorf("polls = %d, want 3", n)
+	}
+	select {
+	case <-f.Done():
+	default:
+		t.Error("Done() not closed after Result()")
+	}
+}
+
+func TestSubmit_Many(t *testing.T) {
+	c, closeFn := newTestClient(t, (&fakeAPI{Pending: 3}).ServeHTTP)
+	defer closeFn()
+
+	const n = 200
+	futures := make([]*Future[KasadaStandardSolution], n)
+	for i := range futures {
+		futures[i] = Submit[KasadaStandardSolution](c, context.Background(), futureOptions, WithPollInterval(5*time.Millisecond))
+	}
+
+	seen := make(map[string]bool)
+	for i, f := range futures {
+		solution, err := f.Result()
+		if err != nil {
+			t.Fatalf("future %d: Result() error: %v", i, err)
+		}
+		if solution.UserAgent != f.TaskID() {
+			t.Errorf("future %d: UserAgent = %q, want %q", i, solution.UserAgent, f.TaskID())
+		}
+		seen[f.TaskID()] = true
+	}
+	if len(seen) != n {
+		t.Errorf("distinct tasks = %d, want %d", len(seen), n)
+	}
+
+	// The poller exits once nothing is outstanding.
+	deadline := time.Now().Add(time.Second)
+	for {
+		c.poller.mu.Lock()
+		running := c.poller.running
+		c.poller.mu.Unlock()
+		if !running {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("poller still running with no outstanding tasks")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSubmit_SlowPollDoesNotBlockOthers(t *testing.T) {
+	var fastPolls atomic.Int32
+	release := make(chan struct{})
+	h := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case EndpointCreateTask:
+			var req struct {
+				Task KasadaStandardOptions `json:"task"`
+			}
+			json.NewDecoder(r.Body).Decode(&req)
+			fmt.Fprintf(w, `{"error_code":0,"taskId":%q}`, req.Task.Pjs)
+		case EndpointGetTaskResult:
+			var req TaskRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			if req.TaskId == "slow" {
+				<-release
+			} else if fastPolls.Add(1) <= 3 {
+				w.Write([]byte(`{"errorId":0,"status":"pending","solution":null}`))
+				return
+			}
+			w.Write([]byte(`{"errorId":0,"status":"ready","solution":{}}`))
+		}
+	}
+	c, closeFn := newTestClient(t, h)
+	defer closeFn()
+	defer close(release)
+
+	slow := Submit[KasadaStandardSolution](c, context.Background(), KasadaStandardOptions{Pjs: "slow"}, WithPollInterval(time.Millisecond))
+	fast := Submit[KasadaStandardSolution](c, context.Background(), KasadaStandardOptions{Pjs: "fast"}, WithPollInterval(time.Millisecond))
+
+	select {
+	case <-fast.Done():
+	case <-time.After(time.Second):
+		t.Fatal("fast task not done while a slow poll was in flight")
+	}
+	if _, err := fast.Result(); err != nil {
+		t.Fatalf("Result() error: %v", err)
+	}
+	select {
+	case <-slow.Done():
+		t.Error("slow task done before its poll returned")
+	default:
+	}
+}
+
+func TestSubmit_Cancel(t *testing.T) {
+	c, closeFn := newTestClient(t, (&fakeAPI{Pending: 1 << 30}).ServeHTTP)
+	defer closeFn()
+
+	f := Submit[KasadaStandardSolution](c, context.Background(), futureOptions, WithPollInterval(time.Millisecond))
+	for f.TaskID() == "" {
+		time.Sleep(time.Millisecond)
+	}
+	f.Cancel()
+
+	select {
+	case <-f.Done():
+	case <-time.After(time.Second):
+		t.Fatal("Done() not closed after Cancel()")
+	}
+	if _, err := f.Result(); !errors.Is(err, context.Canceled) {
+		t.Fatalf("errors.Is(err, context.Canceled) = false, want true; err = %v", err)
+	}
+}
+
+func TestSubmit_MaxWait(t *testing.T) {
+	c, closeFn := newTestClient(t, (&fakeAPI{Pending: 1 << 30}).ServeHTTP)
+	defer closeFn()
+
+	f := Submit[KasadaStandardSolution](c, context.Background(), futureOptions,
+		WithPollInterval(time.Millisecond), WithMaxWait(20*time.Millisecond))
+	if _, err := f.Result(); !errors.Is(err, ErrPollTimeout) {
+		t.Fatalf("errors.Is(err, ErrPollTimeout) = false, want true; err = %v", err)
+	}
+}
+
+func TestSubmit_TaskFailed(t *testing.T) {
+	api := &fakeAPI{TaskId: func(string) string { return "failed-1" }}
+	c, closeFn := newTestClient(t, api.ServeHTTP)
+	defer closeFn()
+
+	f := Submit[KasadaStandardSolution](c, context.Background(), futureOptions, WithPollInterval(time.Millisecond))
+	if _, err := f.Result(); !errors.Is(err, ErrTaskFailed) {
+		t.Fatalf("errors.Is(err, ErrTaskFailed) = false, want true; err = %v", err)
+	}
+}
+
+func TestSubmit_CreateError(t *testing.T) {
+	c, closeFn := newTestClient(t, (&fakeAPI{}).ServeHTTP)
+	defer closeFn()
+
+	f := Submit[KasadaStandardSolution](c, context.Background(), KasadaStandardOptions{})
+	if _, err := f.Result(); !errors.Is(err, ErrInvalidOptions) {
+		t.Fatalf("errors.Is(err, ErrInvalidOptions) = false, want true; err = %v", err)
+	}
+	if f.TaskID() != "" {
+		t.Errorf("TaskID() = %q, want empty", f.TaskID())
+	}
+}
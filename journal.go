@@ -0,0 +1,424 @@
+package salamoonder
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	_ Journal = (*MemoryJournal)(nil)
+	_ Journal = (*FileJournal)(nil)
+)
+
+type (
+	// Journal records created tasks until their outcome is known, so that a
+	// restarted process can pick up tasks it has already paid for. All
+	// methods must be safe for concurrent use.
+	Journal interface {
+		// Created records a newly created task.
+		Created(entry JournalEntry) error
+		// Completed marks a task as finished with a terminal status.
+		Completed(taskId string, status TaskStatus) error
+		// Pending returns the unfinished tasks, oldest first.
+		Pending() ([]JournalEntry, error)
+	}
+
+	// JournalEntry is the journaled state of one task.
+	JournalEntry struct {
+		TaskId      string     `json:"task_id"`
+		TaskType    string     `json:"task_type"`
+		OptionsHash string     `json:"options_hash"`
+		CreatedAt   time.Time  `json:"created_at"`
+		Status      TaskStatus `json:"status,omitempty"`
+		Done        bool       `json:"done"`
+		CompletedAt time.Time  `json:"completed_at,omitzero"`
+	}
+
+	// ResumeResult is the outcome of a task picked up by Resume.
+	ResumeResult struct {
+		Entry  JournalEntry
+		Result *TaskResultRaw
+		Err    error
+	}
+
+	// MemoryJournal is a Journal kept in memory, mainly for tests.
+	MemoryJournal struct {
+		mu      sync.Mutex
+		entries map[string]*JournalEntry
+	}
+
+	// FileJournal is a Journal stored as a JSONL file. Every change appends
+	// the full entry as one line; the last line of a task wins when the file
+	// is opened again. Completed tasks are dropped: the file is rewritten
+	// with only the pending ones when it is opened, and once completed
+	// lines make up most of it.
+	FileJournal struct {
+		mu      sync.Mutex
+		path    string
+		f       *os.File
+		entries map[string]*JournalEntry
+		lines   int
+		noSync  bool
+	}
+
+	// FileJournalOption configures a FileJournal.
+	FileJournalOption func(*FileJournal)
+)
+
+// journalCompactLines is how many lines of completed tasks a FileJournal
+// keeps before it is compacted, as long as they outnumber the pending ones.
+const journalCompactLines = 1000
+
+// WithJournal records every task created by the client, and its completion,
+// in j. Journal write failures are logged, not returned: the task exists on
+// the server either way.
+func WithJournal(j Journal) Option {
+	return func(c *client) {
+		c.journal = j
+	}
+}
+
+// OptionsHash returns a stable hash of options and their task type, hex
+// encoded. Options of unregistered types fail with *MethodError.
+func OptionsHash(options any) (string, error) {
+	taskType, ok := TaskTypeOf(options)
+	if !ok {
+		return "", &MethodError{OptionsValue: options}
+	}
+	data, err := json.Marshal(options)
+	if err != nil {
+		return "", fmt.Errorf("marshal options: %w", err)
+	}
+	h := sha256.New()
+	h.Write([]byte(taskType))
+	h.Write([]byte{0})
+	h.Write(data)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Resume waits for every unfinished task in the client's journal and
+// returns the outcomes in journal order. Tasks are polled by the client's
+// shared poller, and each outcome is recorded in the journal.
+func (c *Client) Resume(ctx context.Context, opts ...PollOption) ([]ResumeResult, error) {
+	if c.journal == nil {
+		return nil, fmt.Errorf("resume: no journal configured")
+	}
+	pending, err := c.journal.Pending()
+	if err != nil {
+		return nil, fmt.Errorf("resume: %w", err)
+	}
+
+	cfg := newPollConfig(opts)
+	results := make([]ResumeResult, len(pending))
+	var wg sync.WaitGroup
+	wg.Add(len(pending))
+	for i, entry := range pending {
+		results[i].Entry = entry
+		c.usage.resumed(entry.TaskId, entry.TaskType)
+		c.poller.add(withTaskType(ctx, entry.TaskType), entry.TaskId, cfg, func(result *TaskResultRaw, err error) {
+			results[i].Result, results[i].Err = result, err
+			wg.Done()
+		})
+	}
+	wg.Wait()
+	return results, nil
+}
+
+// journalCreated records a task created from options. It is a no-op without
+// a journal.
+func (c *Client) journalCreated(ctx context.Context, taskType, taskId string, options any) {
+	if c.journal == nil {
+		return
+	}
+	hash, err := OptionsHash(options)
+	if err == nil {
+		err = c.journal.Created(JournalEntry{
+			TaskId:      taskId,
+			TaskType:    taskType,
+			OptionsHash: hash,
+			CreatedAt:   time.Now(),
+		})
+	}
+	c.journalError(ctx, "created", taskId, err)
+}
+
+// journalResult records the outcome of a /getTaskResult call once the task
+// has reached a terminal status or the API no longer knows it. Other errors,
+// such as a malformed request, say nothing about the task.
+func (c *Client) journalResult(ctx context.Context, taskId string, status TaskStatus, err error) {
+	if c.journal == nil {
+		return
+	}
+	var apiErr *APIError
+	switch {
+	case errors.Is(err, ErrTaskNotFound):
+		status = TaskStatusFailed
+	case errors.As(err, &apiErr) && apiErr.Status.IsTerminal():
+		status = apiErr.Status
+	case err != nil || !status.IsTerminal():
+		return
+	}
+	c.journalError(ctx, "completed", taskId, c.journal.Completed(taskId, status))
+}
+
+func (c *Client) journalError(ctx context.Context, op, taskId string, err error) {
+	if err == nil || c.logger == nil {
+		return
+	}
+	c.logger.LogAttrs(ctx, slog.LevelWarn, "salamoonder journal write failed",
+		slog.String("op", op),
+		slog.String("task_id", taskId),
+		slog.String("error", err.Error()),
+	)
+}
+
+// NewMemoryJournal returns an empty MemoryJournal.
+func NewMemoryJournal() *MemoryJournal {
+	return &MemoryJournal{entries: make(map[string]*JournalEntry)}
+}
+
+func (m *MemoryJournal) Created(entry JournalEntry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.entries[entry.TaskId] = &entry
+	return nil
+}
+
+func (m *MemoryJournal) Completed(taskId string, status TaskStatus) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	complete(m.entries, taskId, status)
+	return nil
+}
+
+func (m *MemoryJournal) Pending() ([]JournalEntry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return pendingEntries(m.entries), nil
+}
+
+// Entries returns every journaled task, finished or not, oldest first.
+func (m *MemoryJournal) Entries() []JournalEntry {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	out := make([]JournalEntry, 0, len(m.entries))
+	for _, e := range m.entries {
+		out = append(out, *e)
+	}
+	sortEntries(out)
+	return out
+}
+
+// WithFileJournalSync sets whether every record is synced to stable storage
+// before Created and Completed return. It is on by default; turning it off
+// trades durability across power loss for speed.
+func WithFileJournalSync(sync bool) FileJournalOption {
+	return func(j *FileJournal) {
+		j.noSync = !sync
+	}
+}
+
+// OpenFileJournal opens or creates the journal file at path, loads the
+// pending entries already in it and compacts it.
+func OpenFileJournal(path string, opts ...FileJournalOption) (*FileJournal, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("open journal: %w", err)
+	}
+
+	j := &FileJournal{path: path, f: f, entries: make(map[string]*JournalEntry)}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(j)
+		}
+	}
+	sc := bufio.NewScanner(f)
+	sc.Buffer(nil, 1<<20)
+	for sc.Scan() {
+		j.lines++
+		var e JournalEntry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			// A crash mid-write can leave a torn last line; skip it.
+			continue
+		}
+		if e.TaskId != "" {
+			j.entries[e.TaskId] = &e
+		}
+	}
+	if err := sc.Err(); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("read journal: %w", err)
+	}
+	for id, e := range j.entries {
+		if e.Done {
+			delete(j.entries, id)
+		}
+	}
+	if j.lines > len(j.entries) {
+		if err := j.compact(); err != nil {
+			j.f.Close()
+			return nil, err
+		}
+	}
+	return j, nil
+}
+
+func (j *FileJournal) Created(entry JournalEntry) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.entries[entry.TaskId] = &entry
+	return j.write(&entry)
+}
+
+func (j *FileJournal) Completed(taskId string, status TaskStatus) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	e := complete(j.entries, taskId, status)
+	if e == nil {
+		return nil
+	}
+	delete(j.entries, taskId)
+	if err := j.write(e); err != nil {
+		return err
+	}
+	if stale := j.lines - len(j.entries); stale >= journalCompactLines && stale > len(j.entries) {
+		return j.compact()
+	}
+	return nil
+}
+
+// Compact rewrites the journal file with only the pending entries.
+func (j *FileJournal) Compact() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return j.compact()
+}
+
+func (j *FileJournal) Pending() ([]JournalEntry, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return pendingEntries(j.entries), nil
+}
+
+// Close closes the journal file.
+func (j *FileJournal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return j.f.Close()
+}
+
+// write appends e as one line. j.mu must be held.
+func (j *FileJournal) write(e *JournalEntry) error {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if _, err := j.f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("write journal: %w", err)
+	}
+	j.lines++
+	if !j.noSync {
+		if err := j.f.Sync(); err != nil {
+			return fmt.Errorf("sync journal: %w", err)
+		}
+	}
+	return nil
+}
+
+// compact writes the pending entries to a new file and renames it over the
+// journal, so that a crash leaves either the old or the new file. j.mu must
+// be held.
+func (j *FileJournal) compact() error {
+	tmp, err := os.CreateTemp(filepath.Dir(j.path), filepath.Base(j.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("compact journal: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	pending := pendingEntries(j.entries)
+	w := bufio.NewWriter(tmp)
+	for i := range pending {
+		line, err := json.Marshal(&pending[i])
+		if err != nil {
+			tmp.Close()
+			return fmt.Errorf("compact journal: %w", err)
+		}
+		w.Write(line)
+		w.WriteByte('\n')
+	}
+	err = w.Flush()
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), j.path)
+	}
+	if err != nil {
+		return fmt.Errorf("compact journal: %w", err)
+	}
+
+	f, err := os.OpenFile(j.path, os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("compact journal: %w", err)
+	}
+	j.f.Close()
+	j.f = f
+	j.lines = len(pending)
+	return nil
+}
+
+// complete marks taskId as done and returns its entry, or nil if the task is
+// unknown or already done.
+func complete(entries map[string]*JournalEntry, taskId string, status TaskStatus) *JournalEntry {
+	e, ok := entries[taskId]
+	if !ok || e.Done {
+		return nil
+	}
+	e.Status = status
+	e.Done = true
+	e.CompletedAt = time.Now()
+	return e
+}
+
+func pendingEntries(entries map[string]*JournalEntry) []JournalEntry {
+	var out []JournalEntry
+	for _, e := range entries {
+		if !e.Done {
+			out = append(out, *e)
+		}
+	}
+	sortEntries(out)
+	return out
+}
+
+func sortEntries(entries []JournalEntry) {
+	sort.Slice(entries, func(a, b int) bool {
+		if !entries[a].CreatedAt.Equal(entries[b].CreatedAt) {
+			return entries[a].CreatedAt.Before(entries[b].CreatedAt)
+		}
+		return entries[a].TaskId < entries[b].TaskId
+	})
+}
@@ -0,0 +1,231 @@
+package salamoonder
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestJournal_Solve(t *testing.T) {
+	journal := NewMemoryJournal()
+	c, closeFn := newTestClient(t, (&fakeAPI{}).ServeHTTP, WithJournal(journal))
+	defer closeFn()
+
+	options := KasadaStandardOptions{Pjs: "https://example.com/p.js"}
+	if _, err := Solve[KasadaStandardSolution](c, context.Background(), options, WithPollInterval(time.Millisecond)); err != nil {
+		t.Fatalf("Solve() error: %v", err)
+	}
+
+	entries := journal.Entries()
+	if len(entries) != 1 {
+		t.Fatalf("Entries() = %d, want 1", len(entries))
+	}
+	e := entries[0]
+	hash, _ := OptionsHash(options)
+	if e.TaskId != "task-1" || e.TaskType != "KasadaCaptchaSolver" || e.OptionsHash != hash {
+		t.Errorf("entry = %+v, want task-1 of KasadaCaptchaSolver with options hash", e)
+	}
+	if !e.Done || e.Status != TaskStatusReady || e.CompletedAt.IsZero() {
+		t.Errorf("entry = %+v, want done with status ready", e)
+	}
+}
+
+func TestJournal_FailedTask(t *testing.T) {
+	journal := NewMemoryJournal()
+	journal.Created(JournalEntry{TaskId: "failed-1", TaskType: "KasadaCaptchaSolver"})
+	c, closeFn := newTestClient(t, (&fakeAPI{}).ServeHTTP, WithJournal(journal))
+	defer closeFn()
+
+	c.Task(context.Background(), "failed-1")
+
+	if pending, _ := journal.Pending(); len(pending) != 0 {
+		t.Fatalf("Pending() = %+v, want none", pending)
+	}
+	if e := journal.Entries()[0]; e.Status != TaskStatusFailed {
+		t.Errorf("Status = %q, want failed", e.Status)
+	}
+}
+
+func TestJournal_OnlyTerminalOutcomesComplete(t *testing.T) {
+	tests := []struct {
+		name string
+		code int
+		body string
+		done bool
+	}{
+		{"invalid request", http.StatusBadRequest, `{"error_code":1,"error_description":"invalid taskId format"}`, false},
+		{"reworded", http.StatusBadRequest, `{"error_code":1,"error_description":"no such task"}`, false},
+		{"404", http.StatusNotFound, `not found`, true},
+		{"not found", http.StatusBadRequest, `{"error_code":1,"error_description":"task not found"}`, true},
+		{"failed", http.StatusOK, `{"errorId":1,"status":"failed","solution":null}`, true},
+		{"error without status", http.StatusOK, `{"errorId":1,"solution":null}`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			journal := NewMemoryJournal()
+			journal.Created(JournalEntry{TaskId: "task-1", TaskType: "KasadaCaptchaSolver"})
+			h := func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.code)
+				w.Write([]byte(tt.body))
+			}
+			c, closeFn := newTestClient(t, h, WithJournal(journal))
+			defer closeFn()
+
+			if _, err := c.Task(context.Background(), "task-1"); err == nil {
+				t.Fatal("Task() error = nil, want API error")
+			}
+			if done := journal.Entries()[0].Done; done != tt.done {
+				t.Errorf("Done = %v, want %v", done, tt.done)
+			}
+		})
+	}
+}
+
+func TestJournal_FileResume(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+
+	// First run: the task is created, then the process "crashes".
+	journal, err := OpenFileJournal(path)
+	if err != nil {
+		t.Fatalf("OpenFileJournal() error: %v", err)
+	}
+	c, closeFn := newTestClient(t, (&fakeAPI{}).ServeHTTP, WithJournal(journal))
+	defer closeFn()
+	if _, err := c.CreateTask(context.Background(), KasadaStandardOptions{Pjs: "https://example.com/p.js"}); err != nil {
+		t.Fatalf("CreateTask() error: %v", err)
+	}
+	journal.Close()
+
+	// Second run: the journal still knows the task and Resume fetches it.
+	journal, err = OpenFileJournal(path)
+	if err != nil {
+		t.Fatalf("OpenFileJournal() error: %v", err)
+	}
+	pending, _ := journal.Pending()
+	if len(pending) != 1 || pending[0].TaskId != "task-1" {
+		t.Fatalf("Pending() = %+v, want task-1", pending)
+	}
+
+	c.journal = journal
+	results, err := c.Resume(context.Background(), WithPollInterval(time.Millisecond))
+	if err != nil {
+		t.Fatalf("Resume() error: %v", err)
+	}
+	if len(results) != 1 || results[0].Err != nil || results[0].Result.Status != TaskStatusReady {
+		t.Fatalf("Resume() = %+v, want task-1 ready", results)
+	}
+	journal.Close()
+
+	journal, err = OpenFileJournal(path)
+	if err != nil {
+		t.Fatalf("OpenFileJournal() error: %v", err)
+	}
+	defer journal.Close()
+	if pending, _ := journal.Pending(); len(pending) != 0 {
+		t.Errorf("Pending() after Resume = %+v, want none", pending)
+	}
+}
+
+func TestJournal_FileConcurrentWriters(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+	journal, err := OpenFileJournal(path)
+	if err != nil {
+		t.Fatalf("OpenFileJournal() error: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := range 100 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			id := fmt.Sprintf("task-%d", i)
+			journal.Created(JournalEntry{TaskId: id, CreatedAt: time.Now()})
+			if i%2 == 0 {
+				journal.Completed(id, TaskStatusReady)
+			}
+		}()
+	}
+	wg.Wait()
+	journal.Close()
+
+	journal, err = OpenFileJournal(path)
+	if err != nil {
+		t.Fatalf("OpenFileJournal() error: %v", err)
+	}
+	defer journal.Close()
+	if pending, _ := journal.Pending(); len(pending) != 50 {
+		t.Errorf("Pending() = %d entries, want 50", len(pending))
+	}
+}
+
+func TestJournal_FileCompacts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+	journal, err := OpenFileJournal(path, WithFileJournalSync(false))
+	if err != nil {
+		t.Fatalf("OpenFileJournal() error: %v", err)
+	}
+	journal.Created(JournalEntry{TaskId: "pending", CreatedAt: time.Now()})
+	for i := range journalCompactLines {
+		id := fmt.Sprintf("task-%d", i)
+		journal.Created(JournalEntry{TaskId: id, CreatedAt: time.Now()})
+		journal.Completed(id, TaskStatusReady)
+	}
+	if n := countLines(t, path); n >= journalCompactLines {
+		t.Errorf("journal lines = %d, want compaction below %d", n, journalCompactLines)
+	}
+	journal.Close()
+
+	// Opening drops the remaining completed lines.
+	journal, err = OpenFileJournal(path)
+	if err != nil {
+		t.Fatalf("OpenFileJournal() error: %v", err)
+	}
+	defer journal.Close()
+	if n := countLines(t, path); n != 1 {
+		t.Errorf("journal lines = %d after reopening, want 1", n)
+	}
+	if pending, _ := journal.Pending(); len(pending) != 1 || pending[0].TaskId != "pending" {
+		t.Errorf("Pending() = %+v, want the pending task", pending)
+	}
+}
+
+func countLines(t *testing.T, path string) int {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error: %v", err)
+	}
+	return bytes.Count(data, []byte("\n"))
+}
+
+func TestResume_NoJournal(t *testing.T) {
+	c, closeFn := newTestClient(t, (&fakeAPI{}).ServeHTTP)
+	defer closeFn()
+
+	if _, err := c.Resume(context.Background()); err == nil {
+		t.Error("Resume() error = nil, want error without journal")
+	}
+}
+
+func TestOptionsHash(t *testing.T) {
+	a, err := OptionsHash(KasadaStandardOptions{Pjs: "a"})
+	if err != nil {
+		t.Fatalf("OptionsHash() error: %v", err)
+	}
+	if again, _ := OptionsHash(KasadaStandardOptions{Pjs: "a"}); again != a {
+		t.Errorf("OptionsHash() not stable: %s != %s", again, a)
+	}
+	if b, _ := OptionsHash(KasadaStandardOptions{Pjs: "b"}); b == a {
+		t.Error("OptionsHash() equal for different options")
+	}
+	if _, err := OptionsHash(42); err == nil {
+		t.Error("OptionsHash(42) error = nil, want *MethodError")
+	}
+}
@@ -0,0 +1,257 @@
+package salamoonder
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// LimitBlock makes calls wait, until their context is done, for the
+	// limiters to admit them. It is the default.
+	LimitBlock LimitMode = iota
+	// LimitFailFast makes calls fail with ErrLimitExceeded instead of waiting.
+	LimitFailFast
+)
+
+type (
+	// Limit caps the request rate and the number of concurrent requests.
+	// Zero fields are unlimited.
+	Limit struct {
+		// Rate is the sustained number of requests per second.
+		Rate float64
+		// Burst is the number of requests allowed at once above Rate.
+		// It defaults to 1.
+		Burst int
+		// MaxConcurrent is the number of requests allowed in flight.
+		MaxConcurrent int
+	}
+
+	LimitMode int
+
+	limiter struct {
+		name   string
+		bucket *tokenBucket
+		sem    chan struct{}
+	}
+
+	limiters struct {
+		mode       LimitMode
+		global     *limiter
+		byEndpoint map[string]*limiter
+		byTaskType map[string]*limiter
+		queued     atomic.Int64
+	}
+
+	tokenBucket struct {
+		mu     sync.Mutex
+		rate   float64
+		burst  float64
+		tokens float64
+		last   time.Time
+	}
+)
+
+// WithRateLimit applies limit to every API call made by the client.
+func WithRateLimit(limit Limit) Option {
+	return func(c *client) {
+		c.limits().global = newLimiter("global", limit)
+	}
+}
+
+// WithEndpointLimit applies limit to calls to a single endpoint,
+// e.g. EndpointCreateTask.
+func WithEndpointLimit(endpoint string, limit Limit) Option {
+	return func(c *client) {
+		c.limits().byEndpoint[endpoint] = newLimiter("endpoint "+endpoint, limit)
+	}
+}
+
+// WithTaskTypeLimit applies limit to calls made for a task type, e.g.
+// "KasadaCaptchaSolver". Result polling made by WaitForResult and Solve
+// counts towards the type of the task being polled.
+func WithTaskTypeLimit(taskType string, limit Limit) Option {
+	return func(c *client) {
+		c.limits().byTaskType[taskType] = newLimiter("task type "+taskType, limit)
+	}
+}
+
+// WithLimitMode selects whether limited calls wait or fail fast.
+func WithLimitMode(mode LimitMode) Option {
+	return func(c *client) {
+		c.limits().mode = mode
+	}
+}
+
+// QueueDepth returns the number of calls currently waiting for a rate limit
+// or concurrency slot.
+func (c *Client) QueueDepth() int {
+	if c.limiters == nil {
+		return 0
+	}
+	return int(c.limiters.queued.Load())
+}
+
+func (c *client) limits() *limiters {
+	if c.limiters == nil {
+		c.limiters = &limiters{
+			byEndpoint: make(map[string]*limiter),
+			byTaskType: make(map[string]*limiter),
+		}
+	}
+	return c.limiters
+}
+
+func newLimiter(name string, limit Limit) *limiter {
+	l := &limiter{name: name}
+	if limit.Rate > 0 {
+		burst := float64(max(limit.Burst, 1))
+		l.bucket = &tokenBucket{
+			rate:   limit.Rate,
+			burst:  burst,
+			tokens: burst,
+		}
+	}
+	if limit.MaxConcurrent > 0 {
+		l.sem = make(chan struct{}, limit.MaxConcurrent)
+	}
+	return l
+}
+
+// acquire admits a call through every applicable limiter. The returned
+// function releases the concurrency slots taken.
+func (ls *limiters) acquire(ctx context.Context, endpoint, taskType string) (func(), error) {
+	if ls == nil {
+		return func() {}, nil
+	}
+
+	chain := make([]*limiter, 0, 3)
+	if ls.global != nil {
+		chain = append(chain, ls.global)
+	}
+	if l := ls.byEndpoint[endpoint]; l != nil {
+		chain = append(chain, l)
+	}
+	if l := ls.byTaskType[taskType]; l != nil && taskType != "" {
+		chain = append(chain, l)
+	}
+
+	held := make([]*limiter, 0, len(chain))
+	release := func() {
+		for _, l := range held {
+			if l.sem != nil {
+				<-l.sem
+			}
+		}
+	}
+
+	for _, l := range chain {
+		if err := ls.wait(ctx, l); err != nil {
+			release()
+			for _, l := range held {
+				l.refund()
+			}
+			return nil, err
+		}
+		held = append(held, l)
+	}
+	return release, nil
+}
+
+// wait admits a call through l. A call that is not admitted leaves l as it
+// found it: a token taken from the bucket is given back when the concurrency
+// slot cannot be had.
+func (ls *limiters) wait(ctx context.Context, l *limiter) error {
+	if ls.mode == LimitFailFast {
+		if l.bucket != nil && !l.bucket.allow(time.Now()) {
+			return fmt.Errorf("%w: %s rate", ErrLimitExceeded, l.name)
+		}
+		if l.sem != nil {
+			select {
+			case l.sem <- struct{}{}:
+			default:
+				l.refund()
+				return fmt.Errorf("%w: %s concurrency", ErrLimitExceeded, l.name)
+			}
+		}
+		return nil
+	}
+
+	ls.queued.Add(1)
+	defer ls.queued.Add(-1)
+
+	if l.bucket != nil {
+		if delay := l.bucket.reserve(time.Now()); delay > 0 {
+			timer := time.NewTimer(delay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				l.bucket.cancel()
+				return ctx.Err()
+			case <-timer.C:
+			}
+		}
+	}
+	if l.sem != nil {
+		select {
+		case <-ctx.Done():
+			l.refund()
+			return ctx.Err()
+		case l.sem <- struct{}{}:
+		}
+	}
+	return nil
+}
+
+// refund gives back the token taken for a call that was not made.
+func (l *limiter) refund() {
+	if l.bucket != nil {
+		l.bucket.cancel()
+	}
+}
+
+func (b *tokenBucket) refill(now time.Time) {
+	if !b.last.IsZero() {
+		elapsed := now.Sub(b.last).Seconds()
+		b.tokens = math.Min(b.burst, b.tokens+elapsed*b.rate)
+	}
+	b.last = now
+}
+
+// allow takes a token if one is available right now.
+func (b *tokenBucket) allow(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill(now)
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// reserve takes a token, going into debt if necessary, and returns how long
+// the caller has to wait until the token is actually available.
+func (b *tokenBucket) reserve(now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill(now)
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// cancel returns a token taken by reserve or allow that is no longer needed.
+func (b *tokenBucket) cancel() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tokens = math.Min(b.burst, b.tokens+1)
+}
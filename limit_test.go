@@ -0,0 +1,159 @@
+package salamoonder
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLimit_MaxConcurrent(t *testing.T) {
+	var inFlight, peak atomic.Int32
+	h := func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"error_code":0,"error_description":"","wallet":"1.00"}`))
+	}
+
+	c, closeFn := newTestClient(t, h, WithRateLimit(Limit{MaxConcurrent: 2}))
+	defer closeFn()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := c.Balance(context.Background()); err != nil {
+				t.Errorf("Balance() error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if p := peak.Load(); p > 2 {
+		t.Errorf("peak concurrency = %d, want <= 2", p)
+	}
+}
+
+func TestLimit_FailFast(t *testing.T) {
+	h := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"error_code":0,"error_description":"","taskId":"task-1"}`))
+	}
+
+	c, closeFn := newTestClient(t, h,
+		WithEndpointLimit(EndpointCreateTask, Limit{Rate: 0.001, Burst: 1}),
+		WithLimitMode(LimitFailFast),
+	)
+	defer closeFn()
+
+	if _, err := c.CreateTask(context.Background(), KasadaStandardOptions{Pjs: "x"}); err != nil {
+		t.Fatalf("CreateTask() error: %v", err)
+	}
+	_, err := c.CreateTask(context.Background(), KasadaStandardOptions{Pjs: "x"})
+	if !errors.Is(err, ErrLimitExceeded) {
+		t.Fatalf("errors.Is(err, ErrLimitExceeded) = false, want true; err = %v", err)
+	}
+
+	// Other endpoints are not limited.
+	if _, err := c.Balance(context.Background()); err != nil {
+		t.Fatalf("Balance() error: %v", err)
+	}
+}
+
+func TestLimit_TaskTypeBlocksUntilContextDone(t *testing.T) {
+	h := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"error_code":0,"error_description":"","taskId":"task-1"}`))
+	}
+
+	c, closeFn := newTestClient(t, h, WithTaskTypeLimit("KasadaCaptchaSolver", Limit{Rate: 0.001}))
+	defer closeFn()
+
+	if _, err := c.CreateTask(context.Background(), KasadaStandardOptions{Pjs: "x"}); err != nil {
+		t.Fatalf("CreateTask() error: %v", err)
+	}
+
+	// A different task type is not affected.
+	if _, err := c.CreateTask(context.Background(), UutmvcOptions{Website: "https://example.com"}); err != nil {
+		t.Fatalf("CreateTask() error: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := c.CreateTask(ctx, KasadaStandardOptions{Pjs: "x"})
+		done <- err
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	if d := c.QueueDepth(); d != 1 {
+		t.Errorf("QueueDepth() = %d, want 1", d)
+	}
+
+	if err := <-done; !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("errors.Is(err, context.DeadlineExceeded) = false, want true; err = %v", err)
+	}
+	if d := c.QueueDepth(); d != 0 {
+		t.Errorf("QueueDepth() = %d, want 0", d)
+	}
+}
+
+func TestLimit_RefusedCallKeepsToken(t *testing.T) {
+	for _, mode := range []LimitMode{LimitFailFast, LimitBlock} {
+		l := newLimiter("global", Limit{Rate: 0.001, MaxConcurrent: 1})
+		ls := &limiters{mode: mode, global: l}
+		l.sem <- struct{}{}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		_, err := ls.acquire(ctx, EndpointCreateTask, "")
+		cancel()
+		if err == nil {
+			t.Fatalf("mode %d: acquire() error = nil with the slot taken", mode)
+		}
+
+		<-l.sem
+		ctx, cancel = context.WithTimeout(context.Background(), 100*time.Millisecond)
+		release, err := ls.acquire(ctx, EndpointCreateTask, "")
+		cancel()
+		if err != nil {
+			t.Fatalf("mode %d: acquire() error: %v, want the token given back", mode, err)
+		}
+		release()
+	}
+}
+
+func TestTokenBucket(t *testing.T) {
+	now := time.Now()
+	b := &tokenBucket{rate: 10, burst: 2, tokens: 2}
+
+	if !b.allow(now) || !b.allow(now) {
+		t.Fatal("allow() = false within burst, want true")
+	}
+	if b.allow(now) {
+		t.Fatal("allow() = true after burst, want false")
+	}
+	if d := b.reserve(now); d != 100*time.Millisecond {
+		t.Errorf("reserve() = %v, want 100ms", d)
+	}
+	b.cancel()
+	if !b.allow(now.Add(100 * time.Millisecond)) {
+		t.Error("allow() = false after refill, want true")
+	}
+}
@@ -0,0 +1,155 @@
+package salamoonder
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// redactedValue replaces secrets in logged requests.
+const redactedValue = "[REDACTED]"
+
+// redactedFields are request fields replaced in logs: the API key, and
+// fields that can hold whole scripts or payloads.
+var redactedFields = map[string]bool{
+	"api_key":        true,
+	"pjs":            true,
+	"script":         true,
+	"script_content": true,
+	"payload":        true,
+}
+
+type (
+	logAttrsKey struct{}
+
+	// callInfo describes a finished API call attempt for logging.
+	callInfo struct {
+		duration   time.Duration
+		httpStatus int
+		taskId     string
+		status     TaskStatus
+		timings    *HTTPTimings
+	}
+)
+
+// WithLogAttrs returns a context whose API calls are logged with attrs in
+// addition to the client's own attributes, e.g. a request or job id.
+func WithLogAttrs(ctx context.Context, attrs ...slog.Attr) context.Context {
+	prev := logAttrsFromContext(ctx)
+	merged := make([]slog.Attr, 0, len(prev)+len(attrs))
+	merged = append(merged, prev...)
+	merged = append(merged, attrs...)
+	return context.WithValue(ctx, logAttrsKey{}, merged)
+}
+
+func logAttrsFromContext(ctx context.Context) []slog.Attr {
+	attrs, _ := ctx.Value(logAttrsKey{}).([]slog.Attr)
+	return attrs
+}
+
+// LogValue redacts the API key and large task fields when the request is
+// logged with log/slog.
+func (r CreateTaskRequest) LogValue() slog.Value {
+	return redactRequest(r)
+}
+
+// LogValue redacts the API key when the request is logged with log/slog.
+func (r TaskRequest) LogValue() slog.Value {
+	return redactRequest(r)
+}
+
+// redactRequest returns v as a JSON-like value with redactedFields replaced
+// at any depth.
+func redactRequest(v any) slog.Value {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return slog.StringValue(fmt.Sprintf("<unloggable %T>", v))
+	}
+	var tree any
+	if err := json.Unmarshal(data, &tree); err != nil {
+		return slog.StringValue(fmt.Sprintf("<unloggable %T>", v))
+	}
+	return slog.AnyValue(redact(tree))
+}
+
+func redact(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, field := range v {
+			if !redactedFields[k] {
+				v[k] = redact(field)
+				continue
+			}
+			if s, ok := field.(string); ok && k != "api_key" {
+				v[k] = fmt.Sprintf("[%d bytes]", len(s))
+			} else if field != nil {
+				v[k] = redactedValue
+			}
+		}
+	case []any:
+		for i := range v {
+			v[i] = redact(v[i])
+		}
+	}
+	return v
+}
+
+// logCall logs one API call attempt. Successful attempts are logged at
+// debug level, attempts that will be retried at warn, and final failures at
+// error, except for calls the caller canceled.
+func (c *client) logCall(ctx context.Context, call *Call, info callInfo, err error, retrying bool, retryIn time.Duration) {
+	if c.logger == nil {
+		return
+	}
+
+	level := slog.LevelDebug
+	switch {
+	case err == nil:
+	case errors.Is(err, context.Canceled):
+		level = slog.LevelDebug
+	case retrying:
+		level = slog.LevelWarn
+	default:
+		level = slog.LevelError
+	}
+	if !c.logger.Enabled(ctx, level) {
+		return
+	}
+
+	attrs := append(make([]slog.Attr, 0, 12), slog.String("endpoint", call.Endpoint))
+	if call.TaskType != "" {
+		attrs = append(attrs, slog.String("task_type", call.TaskType))
+	}
+	if info.taskId != "" {
+		attrs = append(attrs, slog.String("task_id", info.taskId))
+	}
+	if info.status != "" {
+		attrs = append(attrs, slog.String("status", info.status.String()))
+	}
+	if info.httpStatus != 0 {
+		attrs = append(attrs, slog.Int("http_status", info.httpStatus))
+	}
+	attrs = append(attrs,
+		slog.Int("attempt", call.Attempt),
+		slog.Duration("duration", info.duration),
+	)
+	if info.timings != nil {
+		attrs = append(attrs, slog.Any("timings", info.timings))
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+	if retrying {
+		attrs = append(attrs, slog.Duration("retry_in", retryIn))
+	}
+	// Retries repeat the request, so only the final record carries it.
+	if level != slog.LevelWarn {
+		attrs = append(attrs, slog.Any("request", redactRequest(call.Request)))
+	}
+	attrs = append(attrs, logAttrsFromContext(ctx)...)
+
+	c.logger.LogAttrs(ctx, level, "salamoonder api call", attrs...)
+}
@@ -0,0 +1,142 @@
+package salamoonder
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newLogClient(t *testing.T, h http.HandlerFunc, opts ...Option) (*Client, *bytes.Buffer, func()) {
+	t.Helper()
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	c, closeFn := newTestClient(t, h, append([]Option{WithLogger(logger)}, opts...)...)
+	return c, &buf, closeFn
+}
+
+func logRecords(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var records []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var rec map[string]any
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			t.Fatalf("log line is not JSON: %s", line)
+		}
+		records = append(records, rec)
+	}
+	return records
+}
+
+func TestLogging_Solve(t *testing.T) {
+	h := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == EndpointCreateTask {
+			w.Write([]byte(`{"error_code":0,"taskId":"task-1"}`))
+			return
+		}
+		w.Write([]byte(`{"errorId":0,"status":"ready","solution":{}}`))
+	}
+	c, buf, closeFn := newLogClient(t, h)
+	defer closeFn()
+
+	ctx := WithLogAttrs(context.Background(), slog.String("request_id", "req-42"))
+	options := KasadaStandardOptions{Pjs: "https://example.com/secret-p.js"}
+	if _, err := Solve[KasadaStandardSolution](c, ctx, options, WithPollInterval(time.Millisecond)); err != nil {
+		t.Fatalf("Solve() error: %v", err)
+	}
+
+	if strings.Contains(buf.String(), "test-api-key") || strings.Contains(buf.String(), "secret-p.js") {
+		t.Fatalf("log leaks the API key or pjs:\n%s", buf)
+	}
+
+	records := logRecords(t, buf)
+	if len(records) != 2 {
+		t.Fatalf("log records = %d, want 2:\n%s", len(records), buf)
+	}
+	create, poll := records[0], records[1]
+	for _, rec := range records {
+		if rec["level"] != "DEBUG" || rec["task_type"] != "KasadaCaptchaSolver" || rec["task_id"] != "task-1" || rec["request_id"] != "req-42" {
+			t.Errorf("record = %v, want debug with task type, id and request_id", rec)
+		}
+		if rec["attempt"] != float64(1) || rec["http_status"] != float64(200) || rec["duration"] == nil {
+			t.Errorf("record = %v, want attempt, http_status and duration", rec)
+		}
+	}
+	if create["endpoint"] != EndpointCreateTask || poll["endpoint"] != EndpointGetTaskResult || poll["status"] != "ready" {
+		t.Errorf("records = %v, %v; want createTask then ready poll", create, poll)
+	}
+
+	req := create["request"].(map[string]any)
+	task := req["task"].(map[string]any)
+	if req["api_key"] != redactedValue || task["pjs"] != "[31 bytes]" {
+		t.Errorf("request = %v, want redacted api_key and pjs", req)
+	}
+}
+
+func TestLogging_RetryAndFailure(t *testing.T) {
+	var calls atomic.Int32
+	h := func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte(`{"error_code":1,"error_description":"down"}`))
+	}
+	c, buf, closeFn := newLogClient(t, h, WithRetryPolicy(RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond}))
+	defer closeFn()
+
+	if _, err := c.Task(context.Background(), "task-9"); err == nil {
+		t.Fatal("Task() error = nil, want error")
+	}
+
+	records := logRecords(t, buf)
+	if len(records) != 2 {
+		t.Fatalf("log records = %d, want 2:\n%s", len(records), buf)
+	}
+	if records[0]["level"] != "WARN" || records[0]["retry_in"] == nil || records[0]["attempt"] != float64(1) || records[0]["request"] != nil {
+		t.Errorf("first record = %v, want warn with retry_in and no request", records[0])
+	}
+	if records[1]["level"] != "ERROR" || records[1]["http_status"] != float64(503) || records[1]["task_id"] != "task-9" || records[1]["request"] == nil {
+		t.Errorf("second record = %v, want error for task-9 with http_status 503 and the request", records[1])
+	}
+}
+
+func TestLogging_Disabled(t *testing.T) {
+	h := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"error_code":0,"wallet":"1.00000"}`))
+	}
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	c, closeFn := newTestClient(t, h, WithLogger(logger))
+	defer closeFn()
+
+	c.Balance(context.Background())
+	if buf.Len() != 0 {
+		t.Errorf("debug records written at warn level:\n%s", buf.String())
+	}
+}
+
+func TestRequestLogValue(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+	logger.Info("request", "req", CreateTaskRequest{
+		ApiKey: "sr-secret",
+		Task:   map[string]any{"type": "KasadaPayloadSolver", "script_content": strings.Repeat("x", 5000)},
+	})
+
+	out := buf.String()
+	if strings.Contains(out, "sr-secret") || strings.Contains(out, "xxxx") {
+		t.Errorf("LogValue() leaks secrets or script content:\n%s", out)
+	}
+	if !strings.Contains(out, "[5000 bytes]") {
+		t.Errorf("LogValue() = %s, want script_content size", out)
+	}
+}
@@ -0,0 +1,58 @@
+package salamoonder
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+type (
+	// Call describes a single attempt of an API call.
+	Call struct {
+		// Endpoint is the path of the call, e.g. EndpointCreateTask.
+		Endpoint string
+		// Request is the request struct that is marshaled into the body:
+		// CreateTaskRequest or TaskRequest.
+		Request any
+		// TaskType is the Salamoonder task type the call is made for, if known.
+		TaskType string
+		// Attempt is the number of the attempt, starting at 1.
+		Attempt int
+	}
+
+	// Response is the raw outcome of a Call. Handlers return it alongside
+	// API errors so that middleware can inspect failed responses too.
+	Response struct {
+		StatusCode int
+		Header     http.Header
+		Body       []byte
+		Latency    time.Duration
+		// Timings breaks the exchange down by phase. It is only set with
+		// WithHTTPTrace.
+		Timings *HTTPTimings
+	}
+
+	// Handler executes a Call.
+	Handler func(ctx context.Context, call *Call) (*Response, error)
+
+	// Middleware wraps a Handler to observe or modify calls, e.g. for
+	// auditing, metrics or fault injection in tests.
+	Middleware func(next Handler) Handler
+)
+
+// WithMiddleware registers middleware around every API call attempt. The
+// first middleware is the outermost one.
+func WithMiddleware(mw ...Middleware) Option {
+	return func(c *client) {
+		c.middleware = append(c.middleware, mw...)
+	}
+}
+
+func chain(h Handler, mw []Middleware) Handler {
+	for i := len(mw) - 1; i >= 0; i-- {
+		if mw[i] != nil {
+			h = mw[i](h)
+		}
+	}
+	return h
+}
@@ -0,0 +1,122 @@
+package salamoonder
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+)
+
+func TestMiddleware_Observe(t *testing.T) {
+	h := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"error_code":0,"error_description":"","taskId":"task-1"}`))
+	}
+
+	var order []string
+	var seen *Call
+	var seenResp *Response
+	outer := func(next Handler) Handler {
+		return func(ctx context.Context, call *Call) (*Response, error) {
+			order = append(order, "outer")
+			return next(ctx, call)
+		}
+	}
+	inner := func(next Handler) Handler {
+		return func(ctx context.Context, call *Call) (*Response, error) {
+			order = append(order, "inner")
+			resp, err := next(ctx, call)
+			seen, seenResp = call, resp
+			return resp, err
+		}
+	}
+
+	c, closeFn := newTestClient(t, h, WithMiddleware(outer, inner))
+	defer closeFn()
+
+	if _, err := c.CreateTask(context.Background(), KasadaStandardOptions{Pjs: "x"}); err != nil {
+		t.Fatalf("CreateTask() error: %v", err)
+	}
+
+	if len(order) != 2 || order[0] != "outer" || order[1] != "inner" {
+		t.Errorf("middleware order = %v, want [outer inner]", order)
+	}
+	if seen.Endpoint != EndpointCreateTask || seen.TaskType != "KasadaCaptchaSolver" || seen.Attempt != 1 {
+		t.Errorf("Call = %+v", seen)
+	}
+	if _, ok := seen.Request.(CreateTaskRequest); !ok {
+		t.Errorf("Call.Request type = %T, want CreateTaskRequest", seen.Request)
+	}
+	if seenResp.StatusCode != http.StatusOK || len(seenResp.Body) == 0 || seenResp.Latency <= 0 {
+		t.Errorf("Response = %+v", seenResp)
+	}
+}
+
+func TestMiddleware_SeesAPIError(t *testing.T) {
+	h := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"error_code":1,"error_description":"insufficient funds","wallet":""}`))
+	}
+
+	var seenErr error
+	var seenBody []byte
+	mw := func(next Handler) Handler {
+		return func(ctx context.Context, call *Call) (*Response, error) {
+			resp, err := next(ctx, call)
+			seenErr, seenBody = err, resp.Body
+			return resp, err
+		}
+	}
+
+	c, closeFn := newTestClient(t, h, WithMiddleware(mw))
+	defer closeFn()
+
+	if _, err := c.Balance(context.Background()); err == nil {
+		t.Fatal("Balance() expected error, got nil")
+	}
+
+	var apiErr *APIError
+	if !errors.As(seenErr, &apiErr) {
+		t.Fatalf("middleware error type = %T, want *APIError", seenErr)
+	}
+	if len(seenBody) == 0 {
+		t.Error("middleware saw empty body for failed call")
+	}
+}
+
+func TestMiddleware_FaultInjection(t *testing.T) {
+	var calls atomic.Int32
+	h := func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}
+
+	inject := func(next Handler) Handler {
+		return func(ctx context.Context, call *Call) (*Response, error) {
+			if call.Endpoint == EndpointGetTaskResult {
+				return &Response{
+					StatusCode: http.StatusOK,
+					Body:       []byte(`{"errorId":0,"status":"ready","solution":{"user-agent":"injected"}}`),
+				}, nil
+			}
+			return next(ctx, call)
+		}
+	}
+
+	c, closeFn := newTestClient(t, h, WithMiddleware(inject))
+	defer closeFn()
+
+	got, err := GetTaskResult[KasadaStandardSolution](c, context.Background(), "task-1")
+	if err != nil {
+		t.Fatalf("GetTaskResult() error: %v", err)
+	}
+	if got.Solution.UserAgent != "injected" {
+		t.Errorf("GetTaskResult() solution.UserAgent = %q, want injected", got.Solution.UserAgent)
+	}
+	if n := calls.Load(); n != 0 {
+		t.Errorf("server calls = %d, want 0", n)
+	}
+}
@@ -0,0 +1,50 @@
+package salamoonder
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Option configures a Client created with New.
+type Option func(*client)
+
+// WithBaseURL points the client at a different API root, e.g. a local
+// stand-in used in staging. Trailing slashes are ignored.
+func WithBaseURL(baseURL string) Option {
+	return func(c *client) {
+		c.baseURL = strings.TrimRight(baseURL, "/")
+	}
+}
+
+// WithHTTPClient sets the http.Client used for every API call.
+// A nil httpClient keeps the default.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent with every API call.
+func WithUserAgent(userAgent string) Option {
+	return func(c *client) {
+		c.userAgent = userAgent
+	}
+}
+
+// WithDefaultTimeout bounds every API call whose context has no deadline.
+func WithDefaultTimeout(d time.Duration) Option {
+	return func(c *client) {
+		c.timeout = d
+	}
+}
+
+// WithLogger logs every API call attempt to logger: successes at debug
+// level, attempts that are retried at warn and failures at error. The API
+// key and large task fields are redacted; see also WithLogAttrs.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *client) {
+		c.logger = logger
+	}
+}
@@ -0,0 +1,257 @@
+package salamoonder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"time"
+)
+
+const (
+	defaultPollInterval       = 2 * time.Second
+	defaultUnknownStatusLimit = 5
+)
+
+type (
+	// PollOption configures how WaitForResult and Solve poll for a task result.
+	PollOption func(*pollConfig)
+
+	pollConfig struct {
+		interval    time.Duration
+		maxInterval time.Duration
+		multiplier  float64
+		jitter      float64
+		maxWait     time.Duration
+		maxUnknown  int
+	}
+)
+
+// WithPollInterval polls at a fixed interval. This is the default strategy
+// with an interval of 2 seconds.
+func WithPollInterval(d time.Duration) PollOption {
+	return func(cfg *pollConfig) {
+		cfg.interval = d
+		cfg.maxInterval = 0
+		cfg.multiplier = 1
+	}
+}
+
+// WithExponentialBackoff starts polling at initial and multiplies the interval
+// by multiplier after every attempt, never exceeding max.
+func WithExponentialBackoff(initial, max time.Duration, multiplier float64) PollOption {
+	return func(cfg *pollConfig) {
+		cfg.interval = initial
+		cfg.maxInterval = max
+		cfg.multiplier = multiplier
+	}
+}
+
+// WithJitter randomizes every interval by up to ±fraction of its length,
+// e.g. 0.2 turns a 2s interval into a value between 1.6s and 2.4s.
+func WithJitter(fraction float64) PollOption {
+	return func(cfg *pollConfig) {
+		cfg.jitter = fraction
+	}
+}
+
+// WithMaxWait bounds the total time spent waiting for the task. When it
+// elapses the wait fails with ErrPollTimeout.
+func WithMaxWait(d time.Duration) PollOption {
+	return func(cfg *pollConfig) {
+		cfg.maxWait = d
+	}
+}
+
+// WithUnknownStatusLimit sets how many consecutive polls may report a status
+// the library does not know before the wait fails with ErrUnexpectedStatus.
+// The default is 5.
+func WithUnknownStatusLimit(n int) PollOption {
+	return func(cfg *pollConfig) {
+		cfg.maxUnknown = n
+	}
+}
+
+func newPollConfig(opts []PollOption) pollConfig {
+	cfg := pollConfig{
+		interval:   defaultPollInterval,
+		multiplier: 1,
+		maxUnknown: defaultUnknownStatusLimit,
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&cfg)
+		}
+	}
+	if cfg.interval <= 0 {
+		cfg.interval = defaultPollInterval
+	}
+	if cfg.multiplier < 1 {
+		cfg.multiplier = 1
+	}
+	return cfg
+}
+
+// delay returns the wait before poll attempt n (starting at 0).
+func (cfg pollConfig) delay(n int) time.Duration {
+	d := float64(cfg.interval)
+	for i := 0; i < n && cfg.multiplier > 1; i++ {
+		d *= cfg.multiplier
+		if cfg.maxInterval > 0 && d >= float64(cfg.maxInterval) {
+			d = float64(cfg.maxInterval)
+			break
+		}
+	}
+	if cfg.jitter > 0 {
+		d += d * cfg.jitter * (2*rand.Float64() - 1)
+	}
+	if d < 0 {
+		return 0
+	}
+	return time.Duration(d)
+}
+
+// WaitForResult polls Task until the task is ready, fails, or the wait is
+// aborted. Failures are reported as *PollError. The result's TaskTimings
+// count from the call to WaitForResult.
+func (c *Client) WaitForResult(ctx context.Context, taskId string, opts ...PollOption) (*TaskResultRaw, error) {
+	ctx, clock := withTaskClock(ctx, time.Now())
+	result, err := pollTask(c, ctx, taskId, newPollConfig(opts), func(ctx context.Context) (*TaskResultRaw, TaskStatus, error) {
+		result, err := c.Task(ctx, taskId)
+		if err != nil {
+			return nil, "", err
+		}
+		return result, result.Status, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	result.TaskTimings = clock.timings()
+	return result, nil
+}
+
+// Solve creates a task for options and waits for its typed solution.
+// Creation errors are returned as is; errors while waiting are *PollError.
+// The result's TaskTimings break down where the time went. With WithCache a
+// valid cached solution is returned instead of creating a task, and with
+// WithCoalescing concurrent calls for the same options share one task.
+func Solve[TS TaskSolution, TO TaskOptions](c *Client, ctx context.Context, options TO, opts ...PollOption) (*TaskResult[TS], error) {
+	if c.cache != nil {
+		return solveCached[TS](c, ctx, options, opts)
+	}
+	return solveCoalesced(c, ctx, options, func(ctx context.Context) (*TaskResult[TS], error) {
+		return solve[TS](c, ctx, options, opts)
+	})
+}
+
+func solve[TS TaskSolution, TO TaskOptions](c *Client, ctx context.Context, options TO, opts []PollOption) (*TaskResult[TS], error) {
+	ctx, clock := withTaskClock(ctx, time.Now())
+	ctx = withTaskType(ctx, getTaskTypeFromOptions(options))
+	created, err := createTaskGeneric(c, ctx, options)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := pollTask(c, ctx, created.TaskId, newPollConfig(opts), func(ctx context.Context) (*TaskResult[TS], TaskStatus, error) {
+		result, err := GetTaskResult[TS](c, ctx, created.TaskId)
+		if err != nil {
+			return nil, "", err
+		}
+		return result, result.Status, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	result.TaskTimings = clock.timings()
+	return result, nil
+}
+
+func pollTask[R any](c *Client, ctx context.Context, taskId string, cfg pollConfig, fetch func(context.Context) (*R, TaskStatus, error)) (*R, error) {
+	if cfg.maxWait > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.maxWait)
+		defer cancel()
+	}
+
+	unknown := 0
+	for attempt := 0; ; attempt++ {
+		pollCtx, span := c.startSpan(ctx, SpanPoll, slog.String(AttrTaskId, taskId), slog.Int(AttrAttempt, attempt+1))
+		result, status, err := fetch(pollCtx)
+		done, err := pollStep(ctx, taskId, status, err, &unknown, cfg.maxUnknown)
+		endPollSpan(span, status, err)
+		if done {
+			if err != nil {
+				return nil, err
+			}
+			return result, nil
+		}
+
+		timer := time.NewTimer(cfg.delay(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, waitAborted(taskId, ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+// pollStep interprets the outcome of one poll. It reports whether the wait is
+// over and, if so, why it failed. unknown counts consecutive polls with a
+// status the library does not know.
+func pollStep(ctx context.Context, taskId string, status TaskStatus, err error, unknown *int, maxUnknown int) (bool, error) {
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return true, waitAborted(taskId, ctxErr)
+		}
+		if isTaskFailure(err) {
+			return true, &PollError{TaskId: taskId, Kind: ErrTaskFailed, Err: err}
+		}
+		return true, &PollError{TaskId: taskId, Kind: ErrTransport, Err: err}
+	}
+
+	switch {
+	case status.IsReady():
+		return true, nil
+	case status.IsFailed():
+		return true, &PollError{TaskId: taskId, Kind: ErrTaskFailed, Status: status}
+	case !status.Known():
+		if *unknown++; *unknown > maxUnknown {
+			return true, &PollError{TaskId: taskId, Kind: ErrUnexpectedStatus, Status: status}
+		}
+	default:
+		*unknown = 0
+	}
+	return false, nil
+}
+
+func endPollSpan(span Span, status TaskStatus, err error) {
+	if status != "" {
+		span.SetAttributes(slog.String(AttrStatus, status.String()))
+	}
+	span.End(err)
+}
+
+func waitAborted(taskId string, ctxErr error) error {
+	if errors.Is(ctxErr, context.DeadlineExceeded) {
+		return &PollError{TaskId: taskId, Kind: ErrPollTimeout, Err: ctxErr}
+	}
+	return fmt.Errorf("wait for task [%s]: %w", taskId, ctxErr)
+}
+
+// isTaskFailure reports whether err is the API rejecting the task itself,
+// as opposed to the status query failing.
+func isTaskFailure(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	switch apiErr.StatusCode {
+	case http.StatusOK, http.StatusBadRequest, http.StatusNotFound:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,275 @@
+package salamoonder
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// ------------------------------------------------------------------
+// WaitForResult
+// ------------------------------------------------------------------
+
+func TestWaitForResult_ReadyAfterPending(t *testing.T) {
+	var polls atomic.Int32
+	h := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if polls.Add(1) < 3 {
+			w.Write([]byte(`{"errorId":0,"status":"pending","solution":null}`))
+			return
+		}
+		w.Write([]byte(`{"errorId":0,"status":"ready","solution":{"user-agent":"UA"}}`))
+	}
+
+	c, closeFn := newTestClient(t, h)
+	defer closeFn()
+
+	got, err := c.WaitForResult(context.Background(), "task-1", WithPollInterval(time.Millisecond))
+	if err != nil {
+		t.Fatalf("WaitForResult() error: %v", err)
+	}
+	if got.Status != "ready" {
+		t.Fatalf("WaitForResult() status = %q, want ready", got.Status)
+	}
+	if n := polls.Load(); n != 3 {
+		t.Errorf("polls = %d, want 3", n)
+	}
+}
+
+func TestWaitForResult_TaskFailed(t *testing.T) {
+	h := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"errorId":1,"status":"failed","solution":null}`))
+	}
+
+	c, closeFn := newTestClient(t, h)
+	defer closeFn()
+
+	_, err := c.WaitForResult(context.Background(), "task-1", WithPollInterval(time.Millisecond))
+	if !errors.Is(err, ErrTaskFailed) {
+		t.Fatalf("errors.Is(err, ErrTaskFailed) = false, want true; err = %v", err)
+	}
+
+	var pollErr *PollError
+	if !errors.As(err, &pollErr) {
+		t.Fatalf("WaitForResult() error type = %T, want *PollError", err)
+	}
+	if pollErr.TaskId != "task-1" {
+		t.Errorf("PollError.TaskId = %q, want task-1", pollErr.TaskId)
+	}
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Errorf("errors.As(*APIError) = false, want true; err = %v", err)
+	}
+}
+
+func TestWaitForResult_MaxWait(t *testing.T) {
+	h := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"errorId":0,"status":"processing","solution":null}`))
+	}
+
+	c, closeFn := newTestClient(t, h)
+	defer closeFn()
+
+	_, err := c.WaitForResult(context.Background(), "task-1",
+		WithPollInterval(5*time.Millisecond),
+		WithMaxWait(30*time.Millisecond),
+	)
+	if !errors.Is(err, ErrPollTimeout) {
+		t.Fatalf("errors.Is(err, ErrPollTimeout) = false, want true; err = %v", err)
+	}
+}
+
+func TestWaitForResult_UnexpectedStatus(t *testing.T) {
+	var polls atomic.Int32
+	h := func(w http.ResponseWriter, r *http.Request) {
+		polls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"errorId":0,"status":"exploded","solution":null}`))
+	}
+
+	c, closeFn := newTestClient(t, h)
+	defer closeFn()
+
+	_, err := c.WaitForResult(context.Background(), "task-1",
+		WithPollInterval(time.Millisecond),
+		WithUnknownStatusLimit(2),
+	)
+	if !errors.Is(err, ErrUnexpectedStatus) {
+		t.Fatalf("errors.Is(err, ErrUnexpectedStatus) = false, want true; err = %v", err)
+	}
+
+	var pollErr *PollError
+	if errors.As(err, &pollErr) && pollErr.Status != "exploded" {
+		t.Errorf("PollError.Status = %q, want exploded", pollErr.Status)
+	}
+	if n := polls.Load(); n != 3 {
+		t.Errorf("polls = %d, want 3", n)
+	}
+}
+
+func TestWaitForResult_NullStatus(t *testing.T) {
+	var polls atomic.Int32
+	h := func(w http.ResponseWriter, r *http.Request) {
+		polls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"errorId":0,"status":null,"solution":null}`))
+	}
+
+	c, closeFn := newTestClient(t, h)
+	defer closeFn()
+
+	_, err := c.WaitForResult(context.Background(), "task-1",
+		WithPollInterval(time.Millisecond),
+		WithUnknownStatusLimit(2),
+	)
+	if !errors.Is(err, ErrUnexpectedStatus) {
+		t.Fatalf("errors.Is(err, ErrUnexpectedStatus) = false, want true; err = %v", err)
+	}
+
+	var pollErr *PollError
+	if errors.As(err, &pollErr) && pollErr.Status != TaskStatusUnknown {
+		t.Errorf("PollError.Status = %q, want %q", pollErr.Status, TaskStatusUnknown)
+	}
+	if n := polls.Load(); n != 3 {
+		t.Errorf("polls = %d, want 3", n)
+	}
+}
+
+func TestWaitForResult_Transport(t *testing.T) {
+	h := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}
+
+	c, closeFn := newTestClient(t, h)
+	defer closeFn()
+
+	_, err := c.WaitForResult(context.Background(), "task-1", WithPollInterval(time.Millisecond))
+	if !errors.Is(err, ErrTransport) {
+		t.Fatalf("errors.Is(err, ErrTransport) = false, want true; err = %v", err)
+	}
+}
+
+func TestWaitForResult_Canceled(t *testing.T) {
+	h := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"errorId":0,"status":"pending","solution":null}`))
+	}
+
+	c, closeFn := newTestClient(t, h)
+	defer closeFn()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	_, err := c.WaitForResult(ctx, "task-1", WithPollInterval(5*time.Millisecond))
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("errors.Is(err, context.Canceled) = false, want true; err = %v", err)
+	}
+}
+
+// ------------------------------------------------------------------
+// Solve
+// ------------------------------------------------------------------
+
+func TestSolve_Kasada_Success(t *testing.T) {
+	var polls atomic.Int32
+	h := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/createTask":
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"error_code":0,"error_description":"","taskId":"task-1"}`))
+		case "/getTaskResult":
+			w.WriteHeader(http.StatusOK)
+			if polls.Add(1) == 1 {
+				w.Write([]byte(`{"errorId":0,"status":"pending","solution":null}`))
+				return
+			}
+			w.Write([]byte(`{"errorId":0,"status":"ready","solution":{"user-agent":"UA","x-kpsdk-cd":"cd"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}
+
+	c, closeFn := newTestClient(t, h)
+	defer closeFn()
+
+	got, err := Solve[KasadaStandardSolution](c, context.Background(), KasadaStandardOptions{Pjs: "x"},
+		WithExponentialBackoff(time.Millisecond, 4*time.Millisecond, 2),
+		WithJitter(0.1),
+	)
+	if err != nil {
+		t.Fatalf("Solve() error: %v", err)
+	}
+	if got.Solution.UserAgent != "UA" || got.Solution.XKpsdkCd != "cd" {
+		t.Fatalf("Solve() unexpected solution: %+v", got.Solution)
+	}
+}
+
+func TestSolve_CreateError(t *testing.T) {
+	h := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error_code":1,"error_description":"invalid task type"}`))
+	}
+
+	c, closeFn := newTestClient(t, h)
+	defer closeFn()
+
+	_, err := Solve[KasadaStandardSolution](c, context.Background(), KasadaStandardOptions{Pjs: "x"})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("Solve() error type = %T, want *APIError", err)
+	}
+	var pollErr *PollError
+	if errors.As(err, &pollErr) {
+		t.Fatalf("Solve() returned *PollError for a creation failure: %v", err)
+	}
+}
+
+// ------------------------------------------------------------------
+// pollConfig
+// ------------------------------------------------------------------
+
+func TestPollConfig_Delay(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []PollOption
+		n    int
+		want time.Duration
+	}{
+		{"default", nil, 5, defaultPollInterval},
+		{"fixed", []PollOption{WithPollInterval(time.Second)}, 3, time.Second},
+		{"exponential", []PollOption{WithExponentialBackoff(time.Second, time.Minute, 2)}, 3, 8 * time.Second},
+		{"exponential capped", []PollOption{WithExponentialBackoff(time.Second, 5*time.Second, 2)}, 10, 5 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := newPollConfig(tt.opts).delay(tt.n); got != tt.want {
+				t.Errorf("delay(%d) = %v, want %v", tt.n, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPollConfig_Jitter(t *testing.T) {
+	cfg := newPollConfig([]PollOption{WithPollInterval(time.Second), WithJitter(0.2)})
+	for i := 0; i < 100; i++ {
+		if d := cfg.delay(0); d < 800*time.Millisecond || d > 1200*time.Millisecond {
+			t.Fatalf("delay(0) = %v, want within [800ms, 1200ms]", d)
+		}
+	}
+}
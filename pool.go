@@ -0,0 +1,356 @@
+package salamoonder
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	defaultPoolWorkers      = 4
+	defaultPoolRetries      = 2
+	defaultPoolRetryBackoff = time.Second
+)
+
+type (
+	// Job is a task for a Pool to solve.
+	Job struct {
+		// Options are task options of any registered type.
+		Options any
+		// Priority orders queued jobs; higher runs first, equal priorities run
+		// in submission order.
+		Priority int
+		// PollOptions configure how the job's result is awaited.
+		PollOptions []PollOption
+		// Callback, if set, is called from the worker with the outcome.
+		Callback func(JobResult)
+		// Result, if set, receives the outcome. The worker blocks on the
+		// send, so use a buffered channel or keep reading.
+		Result chan<- JobResult
+	}
+
+	// JobResult is the outcome of a Job.
+	JobResult struct {
+		Job    Job
+		TaskId string
+		// Result is the ready task result when Err is nil. Its TaskTimings
+		// count from the job being queued.
+		Result *TaskResultRaw
+		Err    error
+		// Attempts counts task creations, including retries.
+		Attempts int
+		// Duration is the time from the job starting to it finishing.
+		Duration time.Duration
+	}
+
+	// PoolStats is a snapshot of a Pool's counters.
+	PoolStats struct {
+		Queued    int
+		Running   int
+		Succeeded int
+		Failed    int
+		// AvgSolveTime is the mean Duration of succeeded jobs.
+		AvgSolveTime time.Duration
+	}
+
+	// PoolOption configures a Pool.
+	PoolOption func(*poolConfig)
+
+	poolConfig struct {
+		workers      int
+		typeLimits   map[string]int
+		retries      int
+		retryBackoff time.Duration
+	}
+
+	// Pool solves jobs on a fixed number of workers sharing one Client.
+	Pool struct {
+		client *Client
+		cfg    poolConfig
+
+		ctx    context.Context
+		cancel context.CancelFunc
+		wg     sync.WaitGroup
+
+		mu        sync.Mutex
+		cond      *sync.Cond
+		queue     []*poolJob
+		seq       uint64
+		closed    bool
+		running   map[string]int
+		stats     PoolStats
+		solveTime time.Duration
+	}
+
+	poolJob struct {
+		Job
+		ctx      context.Context
+		taskType string
+		seq      uint64
+		queued   time.Time
+	}
+)
+
+// WithWorkers sets the number of workers. The default is 4.
+func WithWorkers(n int) PoolOption {
+	return func(cfg *poolConfig) {
+		cfg.workers = n
+	}
+}
+
+// WithTypeConcurrency caps how many jobs of taskType run at once, e.g.
+// WithTypeConcurrency("KasadaCaptchaSolver", 2). Jobs of other types may
+// overtake queued jobs that are waiting for their type's capacity.
+func WithTypeConcurrency(taskType string, n int) PoolOption {
+	return func(cfg *poolConfig) {
+		if cfg.typeLimits == nil {
+			cfg.typeLimits = make(map[string]int)
+		}
+		cfg.typeLimits[taskType] = n
+	}
+}
+
+// WithJobRetries sets how many times a job is started over after its task
+// creation failed without creating a task: a 429, a refused connection, or
+// a retryable *APIError without a task id. Jobs whose task was created are
+// never started over, even if the task fails. Between attempts the pool
+// waits backoff. The default is 2 retries after 1 second.
+func WithJobRetries(n int, backoff time.Duration) PoolOption {
+	return func(cfg *poolConfig) {
+		cfg.retries = n
+		cfg.retryBackoff = backoff
+	}
+}
+
+// NewPool starts a pool of workers that solve jobs with c.
+func NewPool(c *Client, opts ...PoolOption) *Pool {
+	cfg := poolConfig{
+		workers:      defaultPoolWorkers,
+		retries:      defaultPoolRetries,
+		retryBackoff: defaultPoolRetryBackoff,
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&cfg)
+		}
+	}
+	if cfg.workers <= 0 {
+		cfg.workers = defaultPoolWorkers
+	}
+
+	p := &Pool{
+		client:  c,
+		cfg:     cfg,
+		running: make(map[string]int),
+	}
+	p.cond = sync.NewCond(&p.mu)
+	p.ctx, p.cancel = context.WithCancel(context.Background())
+
+	p.wg.Add(cfg.workers)
+	for range cfg.workers {
+		go p.worker()
+	}
+	return p
+}
+
+// Submit queues job. ctx bounds the job while it is queued and running. It
+// fails with ErrPoolClosed after Shutdown and with *MethodError for
+// unregistered options.
+func (p *Pool) Submit(ctx context.Context, job Job) error {
+	taskType, ok := TaskTypeOf(job.Options)
+	if !ok {
+		return &MethodError{OptionsValue: job.Options}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return ErrPoolClosed
+	}
+	p.seq++
+	j := &poolJob{Job: job, ctx: ctx, taskType: taskType, seq: p.seq, queued: time.Now()}
+	i := sort.Search(len(p.queue), func(i int) bool {
+		return p.queue[i].Priority < j.Priority
+	})
+	p.queue = append(p.queue, nil)
+	copy(p.queue[i+1:], p.queue[i:])
+	p.queue[i] = j
+	p.stats.Queued++
+	p.cond.Signal()
+	return nil
+}
+
+// Stats returns the current counters.
+func (p *Pool) Stats() PoolStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	stats := p.stats
+	if stats.Succeeded > 0 {
+		stats.AvgSolveTime = p.solveTime / time.Duration(stats.Succeeded)
+	}
+	return stats
+}
+
+// Shutdown stops accepting jobs and waits for queued and running jobs to
+// finish. If ctx ends first, running jobs are canceled, queued jobs fail
+// with ErrPoolClosed, and ctx's error is returned once the workers exit.
+func (p *Pool) Shutdown(ctx context.Context) error {
+	p.mu.Lock()
+	p.closed = true
+	p.cond.Broadcast()
+	p.mu.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		p.cancel()
+		return nil
+	case <-ctx.Done():
+	}
+
+	p.mu.Lock()
+	dropped := p.queue
+	p.queue = nil
+	p.stats.Queued = 0
+	p.stats.Failed += len(dropped)
+	p.mu.Unlock()
+
+	p.cancel()
+	for _, j := range dropped {
+		j.deliver(JobResult{Job: j.Job, Err: ErrPoolClosed})
+	}
+	<-drained
+	return ctx.Err()
+}
+
+func (p *Pool) worker() {
+	defer p.wg.Done()
+
+	for {
+		p.mu.Lock()
+		var j *poolJob
+		for {
+			if j = p.next(); j != nil || (p.closed && len(p.queue) == 0) {
+				break
+			}
+			p.cond.Wait()
+		}
+		if j == nil {
+			p.mu.Unlock()
+			return
+		}
+		p.running[j.taskType]++
+		p.stats.Queued--
+		p.stats.Running++
+		p.mu.Unlock()
+
+		result := p.solve(j)
+
+		p.mu.Lock()
+		p.running[j.taskType]--
+		p.stats.Running--
+		if result.Err == nil {
+			p.stats.Succeeded++
+			p.solveTime += result.Duration
+		} else {
+			p.stats.Failed++
+		}
+		p.cond.Broadcast()
+		p.mu.Unlock()
+
+		j.deliver(result)
+	}
+}
+
+// next removes and returns the first queued job whose task type has spare
+// capacity. p.mu must be held.
+func (p *Pool) next() *poolJob {
+	for i, j := range p.queue {
+		if limit, ok := p.cfg.typeLimits[j.taskType]; ok && p.running[j.taskType] >= limit {
+			continue
+		}
+		p.queue = append(p.queue[:i], p.queue[i+1:]...)
+		return j
+	}
+	return nil
+}
+
+func (p *Pool) solve(j *poolJob) JobResult {
+	ctx, cancel := context.WithCancel(j.ctx)
+	defer cancel()
+	stop := context.AfterFunc(p.ctx, cancel)
+	defer stop()
+
+	start := time.Now()
+	result := JobResult{Job: j.Job}
+	for {
+		result.Attempts++
+		// The first attempt's queue time includes the wait in the pool.
+		attemptStart := j.queued
+		if result.Attempts > 1 {
+			attemptStart = time.Now()
+		}
+		result.TaskId, result.Result, result.Err = p.attempt(ctx, j, attemptStart)
+		if result.Err == nil || result.Attempts > p.cfg.retries || !isRetryableJobError(result.Err) {
+			break
+		}
+
+		timer := time.NewTimer(p.cfg.retryBackoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			result.Duration = time.Since(start)
+			return result
+		case <-timer.C:
+		}
+	}
+	result.Duration = time.Since(start)
+	return result
+}
+
+func (p *Pool) attempt(ctx context.Context, j *poolJob, start time.Time) (string, *TaskResultRaw, error) {
+	ctx, _ = withTaskClock(ctx, start)
+	ctx = withTaskType(ctx, j.taskType)
+	created, err := p.client.CreateTask(ctx, j.Options)
+	if err != nil {
+		return "", nil, err
+	}
+	result, err := p.client.WaitForResult(ctx, created.TaskId, j.PollOptions...)
+	return created.TaskId, result, err
+}
+
+func (j *poolJob) deliver(result JobResult) {
+	if j.Callback != nil {
+		j.Callback(result)
+	}
+	if j.Result != nil {
+		j.Result <- result
+	}
+}
+
+// isRetryableJobError reports whether starting a job over is safe: the task
+// was never created, e.g. /createTask was rate limited, the connection was
+// refused, or the API refused the task with a retryable error and no task
+// id. A 5xx does not qualify, since the task may exist behind it. Once a
+// task exists, any failure while waiting for it, a *PollError, is final,
+// since starting over would pay for a new task.
+func isRetryableJobError(err error) bool {
+	var pollErr *PollError
+	if errors.As(err, &pollErr) {
+		return false
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Retryable() && isRefusal(err)
+	}
+	return isUnprocessed(err)
+}
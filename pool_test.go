@@ -0,0 +1,305 @@
+package salamoonder
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newPoolAPI names tasks after their pjs, so that a job for "pending" never
+// finishes.
+func newPoolAPI(onCreate func(pjs string)) *fakeAPI {
+	return &fakeAPI{TaskId: func(pjs string) string { return pjs }, OnCreate: onCreate}
+}
+
+func poolJobFor(pjs string, results chan<- JobResult) Job {
+	return Job{
+		Options:     KasadaStandardOptions{Pjs: pjs},
+		PollOptions: []PollOption{WithPollInterval(time.Millisecond)},
+		Result:      results,
+	}
+}
+
+func TestPool_Priority(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{})
+	var (
+		mu    sync.Mutex
+		order []string
+	)
+	c, closeFn := newTestClient(t, newPoolAPI(func(pjs string) {
+		mu.Lock()
+		order = append(order, pjs)
+		mu.Unlock()
+		if pjs == "blocker" {
+			close(started)
+			<-release
+		}
+	}).ServeHTTP)
+	defer closeFn()
+
+	pool := NewPool(c, WithWorkers(1))
+	results := make(chan JobResult, 4)
+
+	if err := pool.Submit(context.Background(), poolJobFor("blocker", results)); err != nil {
+		t.Fatalf("Submit() error: %v", err)
+	}
+	<-started
+	for _, job := range []struct {
+		pjs      string
+		priority int
+	}{{"low", 1}, {"high", 5}, {"mid", 3}} {
+		j := poolJobFor(job.pjs, results)
+		j.Priority = job.priority
+		if err := pool.Submit(context.Background(), j); err != nil {
+			t.Fatalf("Submit() error: %v", err)
+		}
+	}
+	if q := pool.Stats().Queued; q != 3 {
+		t.Errorf("Stats().Queued = %d, want 3", q)
+	}
+	close(release)
+
+	if err := pool.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error: %v", err)
+	}
+	want := []string{"blocker", "high", "mid", "low"}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("order = %v, want %v", order, want)
+		}
+	}
+
+	stats := pool.Stats()
+	if stats.Succeeded != 4 || stats.Failed != 0 || stats.Queued != 0 || stats.Running != 0 {
+		t.Errorf("Stats() = %+v, want 4 succeeded", stats)
+	}
+	if stats.AvgSolveTime <= 0 {
+		t.Errorf("AvgSolveTime = %v, want > 0", stats.AvgSolveTime)
+	}
+	if len(results) != 4 {
+		t.Errorf("results = %d, want 4", len(results))
+	}
+}
+
+func TestPool_TypeConcurrency(t *testing.T) {
+	var inFlight, maxInFlight atomic.Int32
+	c, closeFn := newTestClient(t, newPoolAPI(func(string) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			m := maxInFlight.Load()
+			if n <= m || maxInFlight.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+	}).ServeHTTP)
+	defer closeFn()
+
+	pool := NewPool(c, WithWorkers(4), WithTypeConcurrency("KasadaCaptchaSolver", 1))
+	var succeeded atomic.Int32
+	for range 6 {
+		j := poolJobFor("task", nil)
+		j.Callback = func(r JobResult) {
+			if r.Err == nil {
+				succeeded.Add(1)
+			}
+		}
+		if err := pool.Submit(context.Background(), j); err != nil {
+			t.Fatalf("Submit() error: %v", err)
+		}
+	}
+	if err := pool.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error: %v", err)
+	}
+
+	if n := succeeded.Load(); n != 6 {
+		t.Errorf("succeeded = %d, want 6", n)
+	}
+	if m := maxInFlight.Load(); m != 1 {
+		t.Errorf("max concurrent KasadaCaptchaSolver jobs = %d, want 1", m)
+	}
+}
+
+func TestPool_RetriesUnprocessedCreate(t *testing.T) {
+	var creates atomic.Int32
+	ready := newPoolAPI(nil).ServeHTTP
+	h := func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == EndpointCreateTask && creates.Add(1) == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		ready(w, r)
+	}
+	c, closeFn := newTestClient(t, h, WithRetryPolicy(RetryPolicy{MaxAttempts: 1}))
+	defer closeFn()
+
+	pool := NewPool(c, WithJobRetries(2, time.Millisecond))
+	results := make(chan JobResult, 1)
+	if err := pool.Submit(context.Background(), poolJobFor("task-1", results)); err != nil {
+		t.Fatalf("Submit() error: %v", err)
+	}
+
+	r := <-results
+	if r.Err != nil {
+		t.Fatalf("JobResult.Err = %v", r.Err)
+	}
+	if r.Attempts != 2 || r.TaskId != "task-1" {
+		t.Errorf("JobResult = %+v, want 2 attempts for task-1", r)
+	}
+	pool.Shutdown(context.Background())
+}
+
+func TestPool_RetriesRefusedCreate(t *testing.T) {
+	var creates atomic.Int32
+	ready := newPoolAPI(nil).ServeHTTP
+	h := func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == EndpointCreateTask && creates.Add(1) == 1 {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"error_code":1,"error_description":"solver unavailable"}`))
+			return
+		}
+		ready(w, r)
+	}
+	c, closeFn := newTestClient(t, h, WithRetryPolicy(RetryPolicy{MaxAttempts: 1}))
+	defer closeFn()
+
+	pool := NewPool(c, WithJobRetries(2, time.Millisecond))
+	results := make(chan JobResult, 1)
+	if err := pool.Submit(context.Background(), poolJobFor("task-1", results)); err != nil {
+		t.Fatalf("Submit() error: %v", err)
+	}
+
+	r := <-results
+	if r.Err != nil {
+		t.Fatalf("JobResult.Err = %v", r.Err)
+	}
+	if r.Attempts != 2 || creates.Load() != 2 {
+		t.Errorf("JobResult = %+v after %d creates, want 2 attempts", r, creates.Load())
+	}
+	pool.Shutdown(context.Background())
+}
+
+func TestPool_NoRetryOnFailedTask(t *testing.T) {
+	var creates atomic.Int32
+	h := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == EndpointCreateTask {
+			creates.Add(1)
+			w.Write([]byte(`{"error_code":0,"taskId":"task-1"}`))
+			return
+		}
+		w.Write([]byte(`{"errorId":1,"status":"failed","solution":null}`))
+	}
+	c, closeFn := newTestClient(t, h)
+	defer closeFn()
+
+	pool := NewPool(c, WithJobRetries(3, time.Millisecond))
+	results := make(chan JobResult, 1)
+	pool.Submit(context.Background(), poolJobFor("task-1", results))
+
+	r := <-results
+	if !errors.Is(r.Err, ErrTaskFailed) || r.Attempts != 1 {
+		t.Errorf("JobResult = %+v, want ErrTaskFailed after 1 attempt", r)
+	}
+	if n := creates.Load(); n != 1 {
+		t.Errorf("tasks created = %d, want 1", n)
+	}
+	pool.Shutdown(context.Background())
+}
+
+func TestPool_NoRetryOnServerError(t *testing.T) {
+	var creates atomic.Int32
+	h := func(w http.ResponseWriter, r *http.Request) {
+		creates.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	c, closeFn := newTestClient(t, h)
+	defer closeFn()
+
+	pool := NewPool(c, WithJobRetries(3, time.Millisecond))
+	results := make(chan JobResult, 1)
+	pool.Submit(context.Background(), poolJobFor("task-1", results))
+
+	// The task may have been created before the server failed.
+	if r := <-results; r.Err == nil || r.Attempts != 1 || creates.Load() != 1 {
+		t.Errorf("JobResult = %+v after %d creates, want an error after 1 attempt", r, creates.Load())
+	}
+	pool.Shutdown(context.Background())
+}
+
+func TestPool_NoRetryOnPermanentError(t *testing.T) {
+	h := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"error_code":1,"error_description":"invalid api key"}`))
+	}
+	c, closeFn := newTestClient(t, h)
+	defer closeFn()
+
+	pool := NewPool(c, WithJobRetries(3, time.Millisecond))
+	results := make(chan JobResult, 1)
+	pool.Submit(context.Background(), poolJobFor("task-1", results))
+
+	r := <-results
+	if !errors.Is(r.Err, ErrInvalidAPIKey) || r.Attempts != 1 {
+		t.Errorf("JobResult = %+v, want ErrInvalidAPIKey after 1 attempt", r)
+	}
+	pool.Shutdown(context.Background())
+	if f := pool.Stats().Failed; f != 1 {
+		t.Errorf("Stats().Failed = %d, want 1", f)
+	}
+}
+
+func TestPool_ShutdownTimeout(t *testing.T) {
+	c, closeFn := newTestClient(t, newPoolAPI(nil).ServeHTTP)
+	defer closeFn()
+
+	pool := NewPool(c, WithWorkers(1))
+	results := make(chan JobResult, 2)
+	pool.Submit(context.Background(), poolJobFor("pending", results))
+	pool.Submit(context.Background(), poolJobFor("queued", results))
+	for pool.Stats().Running == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := pool.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Shutdown() error = %v, want context.DeadlineExceeded", err)
+	}
+
+	got := map[string]error{}
+	for range 2 {
+		r := <-results
+		got[r.Job.Options.(KasadaStandardOptions).Pjs] = r.Err
+	}
+	if !errors.Is(got["pending"], context.Canceled) {
+		t.Errorf("running job error = %v, want context.Canceled", got["pending"])
+	}
+	if !errors.Is(got["queued"], ErrPoolClosed) {
+		t.Errorf("queued job error = %v, want ErrPoolClosed", got["queued"])
+	}
+
+	if err := pool.Submit(context.Background(), poolJobFor("late", nil)); !errors.Is(err, ErrPoolClosed) {
+		t.Errorf("Submit() after Shutdown error = %v, want ErrPoolClosed", err)
+	}
+	if stats := pool.Stats(); stats.Failed != 2 || stats.Running != 0 || stats.Queued != 0 {
+		t.Errorf("Stats() = %+v, want 2 failed", stats)
+	}
+}
+
+func TestPool_SubmitUnregistered(t *testing.T) {
+	pool := NewPool(&Client{client: &client{}})
+	defer pool.Shutdown(context.Background())
+
+	err := pool.Submit(context.Background(), Job{Options: 42})
+	if !errors.Is(err, ErrUnsupportedTaskOptionsType) {
+		t.Errorf("errors.Is(err, ErrUnsupportedTaskOptionsType) = false, want true; err = %v", err)
+	}
+}
@@ -0,0 +1,195 @@
+package salamoonder
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+func init() {
+	RegisterTaskType[KasadaStandardOptions, KasadaStandardSolution]("KasadaCaptchaSolver")
+	RegisterTaskType[KasadaPayloadOptions, KasadaPayloadSolution]("KasadaPayloadSolver")
+	RegisterTaskType[AkamaiWebOptions, AkamaiWebSolution]("AkamaiWebSolver")
+	RegisterTaskType[AkamaiSBSDOptions, AkamaiSBSDSolution]("AkamaiSBSDSolver")
+	RegisterTaskType[Reese84Options, Reese84Solution]("IncapsulaReese84Solver")
+	RegisterSolutionType[Reese84Options, Reese84SubmitPayloadSolution](func(o Reese84Options) bool { return o.SubmitPayload })
+	RegisterTaskType[UutmvcOptions, UutmvcSolution]("IncapsulaUTMVCSolver")
+	RegisterTaskType[DataDomeInterstitialOptions, DataDomeInterstitialSolution]("DataDomeInterstitialSolver")
+	RegisterTaskType[DataDomeSliderOptions, DataDomeSliderSolution]("DataDomeSliderSolver")
+	RegisterTaskType[TwitchScraperOptions, TwitchScraperSolution]("Twitch_Scraper")
+	RegisterTaskType[TwitchIntegrityOptions, TwitchIntegritySolution]("Twitch_PublicIntegrity")
+}
+
+type (
+	taskTypeInfo struct {
+		name         string
+		optionsType  reflect.Type
+		solutionType reflect.Type
+		// variants are alternative solution types chosen by the options.
+		variants []solutionVariant
+		create   func(c *Client, ctx context.Context, options any) (*CreateTaskResult, error)
+	}
+
+	solutionVariant struct {
+		match        func(options any) bool
+		solutionType reflect.Type
+	}
+
+	taskRegistry struct {
+		mu        sync.RWMutex
+		byOptions map[reflect.Type]*taskTypeInfo
+		byName    map[string]*taskTypeInfo
+		order     []*taskTypeInfo
+	}
+)
+
+var registry = &taskRegistry{
+	byOptions: make(map[reflect.Type]*taskTypeInfo),
+	byName:    make(map[string]*taskTypeInfo),
+}
+
+/*
+RegisterTaskType makes the options type TO available to CreateTask and Solve
+under the Salamoonder task type name, with TS as its solution type. It lets
+downstream packages use task types the library does not support yet.
+
+RegisterTaskType panics if name or TO is already registered, so call it
+from an init function.
+*/
+func RegisterTaskType[TO TaskOptions, TS TaskSolution](name string) {
+	if name == "" {
+		panic("salamoonder: RegisterTaskType called with an empty name")
+	}
+
+	t := &taskTypeInfo{
+		name:         name,
+		optionsType:  reflect.TypeFor[TO](),
+		solutionType: reflect.TypeFor[TS](),
+		create: func(c *Client, ctx context.Context, options any) (*CreateTaskResult, error) {
+			return createTaskGeneric(c, ctx, options.(TO))
+		},
+	}
+
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	if _, ok := registry.byName[name]; ok {
+		panic(fmt.Sprintf("salamoonder: task type %q registered twice", name))
+	}
+	if prev, ok := registry.byOptions[t.optionsType]; ok {
+		panic(fmt.Sprintf("salamoonder: %v already registered as %q", t.optionsType, prev.name))
+	}
+	registry.byName[name] = t
+	registry.byOptions[t.optionsType] = t
+	registry.order = append(registry.order, t)
+}
+
+/*
+RegisterSolutionType makes TS the solution type of tasks created with options
+of type TO for which match returns true, in place of the solution type TO
+was registered with. It is for options that change the shape of the
+solution, like Reese84Options.SubmitPayload. TaskResultRaw.DecodeSolutionFor
+applies it; decoding by name alone cannot.
+
+RegisterSolutionType panics if TO is not registered, so call it from an init
+function after RegisterTaskType.
+*/
+func RegisterSolutionType[TO TaskOptions, TS TaskSolution](match func(TO) bool) {
+	optionsType := reflect.TypeFor[TO]()
+
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	t, ok := registry.byOptions[optionsType]
+	if !ok {
+		panic(fmt.Sprintf("salamoonder: RegisterSolutionType called for unregistered %v", optionsType))
+	}
+	t.variants = append(t.variants, solutionVariant{
+		match:        func(options any) bool { return match(options.(TO)) },
+		solutionType: reflect.TypeFor[TS](),
+	})
+}
+
+// TaskTypes returns the names of all registered task types, sorted.
+func TaskTypes() []string {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+
+	names := make([]string, 0, len(registry.order))
+	for _, t := range registry.order {
+		names = append(names, t.name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// TaskTypeOf returns the task type name options are registered under.
+func TaskTypeOf(options any) (string, bool) {
+	t := registry.lookup(options)
+	if t == nil {
+		return "", false
+	}
+	return t.name, true
+}
+
+// DecodeTaskOptions decodes data into the options type registered under the
+// task type name. Unknown JSON fields are rejected. The returned value can
+// be passed to CreateTask.
+func DecodeTaskOptions(name string, data []byte) (any, error) {
+	t := registry.lookupName(name)
+	if t == nil {
+		return nil, fmt.Errorf("%w: unknown task type %q; registered: %v", ErrUnsupportedTaskOptionsType, name, TaskTypes())
+	}
+
+	ptr := reflect.New(t.optionsType)
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(ptr.Interface()); err != nil {
+		return nil, fmt.Errorf("decode %s options: %w", name, err)
+	}
+	return ptr.Elem().Interface(), nil
+}
+
+func (r *taskRegistry) lookup(options any) *taskTypeInfo {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.byOptions[reflect.TypeOf(options)]
+}
+
+func (r *taskRegistry) lookupName(name string) *taskTypeInfo {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.byName[name]
+}
+
+// solutionTypeFor returns the task type options are registered under and
+// the solution type their task produces.
+func (r *taskRegistry) solutionTypeFor(options any) (*taskTypeInfo, reflect.Type) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	t := r.byOptions[reflect.TypeOf(options)]
+	if t == nil {
+		return nil, nil
+	}
+	for _, v := range t.variants {
+		if v.match(options) {
+			return t, v.solutionType
+		}
+	}
+	return t, t.solutionType
+}
+
+// registered returns the registered task types in registration order.
+func (r *taskRegistry) registered() []*taskTypeInfo {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return append([]*taskTypeInfo(nil), r.order...)
+}
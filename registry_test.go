@@ -0,0 +1,131 @@
+package salamoonder
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+)
+
+type testCustomOptions struct {
+	Website string `json:"website"`
+}
+
+type testCustomSolution struct {
+	Token string `json:"token"`
+}
+
+func init() {
+	RegisterTaskType[testCustomOptions, testCustomSolution]("TestCustomSolver")
+}
+
+func TestRegisterTaskType_Custom(t *testing.T) {
+	var gotType string
+	h := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		switch r.URL.Path {
+		case EndpointCreateTask:
+			var req struct {
+				Task map[string]any `json:"task"`
+			}
+			json.NewDecoder(r.Body).Decode(&req)
+			gotType, _ = req.Task["type"].(string)
+			w.Write([]byte(`{"error_code":0,"error_description":"","taskId":"f-1"}`))
+		case EndpointGetTaskResult:
+			w.Write([]byte(`{"errorId":0,"status":"ready","solution":{"token":"tok"}}`))
+		}
+	}
+
+	c, closeFn := newTestClient(t, h)
+	defer closeFn()
+
+	if _, err := c.CreateTask(context.Background(), testCustomOptions{Website: "https://example.com"}); err != nil {
+		t.Fatalf("CreateTask() error: %v", err)
+	}
+	if gotType != "TestCustomSolver" {
+		t.Errorf("task type = %q, want TestCustomSolver", gotType)
+	}
+
+	got, err := Solve[testCustomSolution](c, context.Background(), testCustomOptions{})
+	if err != nil {
+		t.Fatalf("Solve() error: %v", err)
+	}
+	if got.Solution.Token != "tok" {
+		t.Errorf("Solve() solution.Token = %q, want tok", got.Solution.Token)
+	}
+}
+
+func TestRegisterTaskType_Duplicate(t *testing.T) {
+	tests := []struct {
+		name     string
+		register func()
+	}{
+		{"name", func() { RegisterTaskType[struct{ A int }, testCustomSolution]("KasadaCaptchaSolver") }},
+		{"options", func() { RegisterTaskType[KasadaStandardOptions, KasadaStandardSolution]("Other") }},
+		{"empty", func() { RegisterTaskType[struct{ B int }, testCustomSolution]("") }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("RegisterTaskType() did not panic")
+				}
+			}()
+			tt.register()
+		})
+	}
+}
+
+func TestTaskTypes(t *testing.T) {
+	names := TaskTypes()
+	for _, want := range []string{"KasadaCaptchaSolver", "Twitch_PublicIntegrity", "TestCustomSolver"} {
+		if !slices.Contains(names, want) {
+			t.Errorf("TaskTypes() = %v, missing %s", names, want)
+		}
+	}
+	if !slices.IsSorted(names) {
+		t.Errorf("TaskTypes() = %v, want sorted", names)
+	}
+
+	if name, ok := TaskTypeOf(DataDomeSliderOptions{}); !ok || name != "DataDomeSliderSolver" {
+		t.Errorf("TaskTypeOf(DataDomeSliderOptions{}) = %q, %v", name, ok)
+	}
+	if _, ok := TaskTypeOf(TwitchLocalIntegrityOptions{}); ok {
+		t.Error("TaskTypeOf(TwitchLocalIntegrityOptions{}) = true, want false")
+	}
+}
+
+func TestMethodError_ListsRegistered(t *testing.T) {
+	c, _ := New("test-api-key", nil)
+
+	_, err := c.CreateTask(context.Background(), nil)
+	if !errors.Is(err, ErrUnsupportedTaskOptionsType) {
+		t.Fatalf("errors.Is(err, ErrUnsupportedTaskOptionsType) = false, want true; err = %v", err)
+	}
+	if msg := err.Error(); !strings.Contains(msg, "KasadaStandardOptions (KasadaCaptchaSolver)") {
+		t.Errorf("MethodError.Error() = %q, want registered types listed", msg)
+	}
+}
+
+func TestDecodeTaskOptions(t *testing.T) {
+	got, err := DecodeTaskOptions("IncapsulaReese84Solver", []byte(`{"website":"https://example.com","submit_payload":true}`))
+	if err != nil {
+		t.Fatalf("DecodeTaskOptions() error: %v", err)
+	}
+	want := Reese84Options{Website: "https://example.com", SubmitPayload: true}
+	if got != want {
+		t.Errorf("DecodeTaskOptions() = %#v, want %#v", got, want)
+	}
+
+	if _, err := DecodeTaskOptions("IncapsulaReese84Solver", []byte(`{"site":"x"}`)); err == nil {
+		t.Error("DecodeTaskOptions() with unknown field expected error, got nil")
+	}
+	if _, err := DecodeTaskOptions("NoSuchSolver", []byte(`{}`)); !errors.Is(err, ErrUnsupportedTaskOptionsType) {
+		t.Errorf("errors.Is(err, ErrUnsupportedTaskOptionsType) = false, want true; err = %v", err)
+	}
+}
@@ -0,0 +1,160 @@
+package salamoonder
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+type (
+	// RetryPolicy controls how failed API calls are retried. The zero value
+	// disables retries.
+	RetryPolicy struct {
+		// MaxAttempts is the total number of attempts, including the first.
+		MaxAttempts int
+		// InitialBackoff is the wait before the first retry. It grows by
+		// Multiplier after every retry, up to MaxBackoff.
+		InitialBackoff time.Duration
+		// MaxBackoff caps the wait between attempts. A Retry-After asking
+		// for a longer wait ends the retries, leaving the error's
+		// RetryAfter to the caller.
+		MaxBackoff time.Duration
+		Multiplier float64
+		// Jitter randomizes every wait by up to ±Jitter of its length.
+		Jitter float64
+		// RetryCreateTask allows retrying /createTask on failures after which
+		// the task may already exist, which risks paying for it twice.
+		// Without it, /createTask is only retried when the request was
+		// provably not processed (connection refused, HTTP 429).
+		RetryCreateTask bool
+		// OnRetry, if set, is called before every retry.
+		OnRetry func(RetryEvent)
+	}
+
+	// RetryEvent describes a failed attempt that is about to be retried.
+	RetryEvent struct {
+		Endpoint string
+		// Attempt is the number of the failed attempt, starting at 1.
+		Attempt int
+		Delay   time.Duration
+		Err     error
+	}
+)
+
+// DefaultRetryPolicy returns a policy making up to 3 attempts with
+// exponential backoff starting at 500ms.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: 500 * time.Millisecond,
+		MaxBackoff:     5 * time.Second,
+		Multiplier:     2,
+		Jitter:         0.2,
+	}
+}
+
+// WithRetryPolicy sets the retry policy for all endpoints.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *client) {
+		c.retryPolicy = policy
+	}
+}
+
+// WithEndpointRetryPolicy overrides the retry policy for a single endpoint,
+// e.g. EndpointGetTaskResult.
+func WithEndpointRetryPolicy(endpoint string, policy RetryPolicy) Option {
+	return func(c *client) {
+		if c.endpointRetryPolicies == nil {
+			c.endpointRetryPolicies = make(map[string]RetryPolicy)
+		}
+		c.endpointRetryPolicies[endpoint] = policy
+	}
+}
+
+func (c *client) retryPolicyFor(endpoint string) RetryPolicy {
+	if policy, ok := c.endpointRetryPolicies[endpoint]; ok {
+		return policy
+	}
+	return c.retryPolicy
+}
+
+// next reports whether the failed attempt should be retried and how long to
+// wait before doing so.
+func (p RetryPolicy) next(ctx context.Context, endpoint string, attempt int, err error) (time.Duration, bool) {
+	if attempt >= p.MaxAttempts || ctx.Err() != nil {
+		return 0, false
+	}
+	if !isRetryable(err) {
+		return 0, false
+	}
+	if endpoint == EndpointCreateTask && !p.RetryCreateTask && !isUnprocessed(err) {
+		return 0, false
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+		if p.MaxBackoff > 0 && apiErr.RetryAfter > p.MaxBackoff {
+			return 0, false
+		}
+		return apiErr.RetryAfter, true
+	}
+
+	backoff := pollConfig{
+		interval:    p.InitialBackoff,
+		maxInterval: p.MaxBackoff,
+		multiplier:  max(p.Multiplier, 1),
+		jitter:      p.Jitter,
+	}
+	return backoff.delay(attempt - 1), true
+}
+
+// isRetryable reports whether err is a transient failure.
+func isRetryable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Retryable()
+	}
+
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return true
+	}
+	return errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// isUnprocessed reports whether err guarantees that the server did not act
+// on the request: an HTTP 429 or a failure to dial. Other errors that match
+// ErrRateLimited do not qualify.
+func isUnprocessed(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode == http.StatusTooManyRequests
+	}
+
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// parseRetryAfter parses a Retry-After header given either in seconds or as
+// an HTTP date.
+func parseRetryAfter(v string) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(time.Until(t), 0)
+	}
+	return 0
+}
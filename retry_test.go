@@ -0,0 +1,262 @@
+package salamoonder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func testRetryPolicy(events *atomic.Int32) RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     5 * time.Millisecond,
+		Multiplier:     2,
+		OnRetry: func(RetryEvent) {
+			events.Add(1)
+		},
+	}
+}
+
+func TestRetry_ServerErrorThenSuccess(t *testing.T) {
+	var calls, retries atomic.Int32
+	h := func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"error_code":0,"error_description":"","wallet":"1.00"}`))
+	}
+
+	c, closeFn := newTestClient(t, h, WithRetryPolicy(testRetryPolicy(&retries)))
+	defer closeFn()
+
+	got, err := c.Balance(context.Background())
+	if err != nil {
+		t.Fatalf("Balance() error: %v", err)
+	}
+	if got.Wallet != "1.00" {
+		t.Errorf("Balance() = %v, want 1.00", got.Wallet)
+	}
+	if n := retries.Load(); n != 2 {
+		t.Errorf("OnRetry calls = %d, want 2", n)
+	}
+}
+
+// HTTP 200, ErrorCode = 1
+func TestRetry_APIErrorExhausted(t *testing.T) {
+	var calls, retries atomic.Int32
+	h := func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"errorId":1,"solution":null}`))
+	}
+
+	c, closeFn := newTestClient(t, h, WithRetryPolicy(testRetryPolicy(&retries)))
+	defer closeFn()
+
+	_, err := c.Task(context.Background(), "task-1")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("Task() error type = %T, want *APIError", err)
+	}
+	if n := calls.Load(); n != 3 {
+		t.Errorf("calls = %d, want 3", n)
+	}
+}
+
+func TestRetry_NoRetryOnFailedTask(t *testing.T) {
+	var calls atomic.Int32
+	h := func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"errorId":1,"status":"failed","solution":null}`))
+	}
+
+	c, closeFn := newTestClient(t, h, WithRetryPolicy(DefaultRetryPolicy()))
+	defer closeFn()
+
+	_, err := c.WaitForResult(context.Background(), "task-1", WithPollInterval(time.Millisecond))
+	if !errors.Is(err, ErrTaskFailed) {
+		t.Fatalf("errors.Is(err, ErrTaskFailed) = false, want true; err = %v", err)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("calls = %d, want 1 for a task that already failed", n)
+	}
+}
+
+func TestRetry_BadRequestNotRetried(t *testing.T) {
+	var calls, retries atomic.Int32
+	h := func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error_code":1,"error_description":"task not found"}`))
+	}
+
+	c, closeFn := newTestClient(t, h, WithRetryPolicy(testRetryPolicy(&retries)))
+	defer closeFn()
+
+	if _, err := c.Task(context.Background(), "task-1"); err == nil {
+		t.Fatal("Task() expected error on 400, got nil")
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("calls = %d, want 1", n)
+	}
+}
+
+func TestRetry_CreateTaskGuard(t *testing.T) {
+	var calls, retries atomic.Int32
+	h := func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+
+	c, closeFn := newTestClient(t, h, WithRetryPolicy(testRetryPolicy(&retries)))
+	defer closeFn()
+
+	if _, err := c.CreateTask(context.Background(), KasadaStandardOptions{Pjs: "x"}); err == nil {
+		t.Fatal("CreateTask() expected error on 500, got nil")
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("calls = %d, want 1 without RetryCreateTask", n)
+	}
+
+	policy := testRetryPolicy(&retries)
+	policy.RetryCreateTask = true
+	calls.Store(0)
+	c, closeFn2 := newTestClient(t, h, WithEndpointRetryPolicy(EndpointCreateTask, policy))
+	defer closeFn2()
+
+	if _, err := c.CreateTask(context.Background(), KasadaStandardOptions{Pjs: "x"}); err == nil {
+		t.Fatal("CreateTask() expected error on 500, got nil")
+	}
+	if n := calls.Load(); n != 3 {
+		t.Errorf("calls = %d, want 3 with RetryCreateTask", n)
+	}
+}
+
+func TestRetry_CreateTaskRateLimited(t *testing.T) {
+	var calls, retries atomic.Int32
+	h := func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"error_code":0,"error_description":"","taskId":"task-1"}`))
+	}
+
+	c, closeFn := newTestClient(t, h, WithRetryPolicy(testRetryPolicy(&retries)))
+	defer closeFn()
+
+	result, err := c.CreateTask(context.Background(), KasadaStandardOptions{Pjs: "x"})
+	if err != nil {
+		t.Fatalf("CreateTask() error: %v", err)
+	}
+	if result.TaskId != "task-1" {
+		t.Errorf("CreateTask() = %q, want task-1", result.TaskId)
+	}
+}
+
+func TestIsUnprocessed(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"429", &APIError{StatusCode: http.StatusTooManyRequests}, true},
+		{"dial", &url.Error{Op: "Post", Err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}}, true},
+		{"read", &url.Error{Op: "Post", Err: &net.OpError{Op: "read", Err: errors.New("connection reset")}}, false},
+		{"503", &APIError{StatusCode: http.StatusServiceUnavailable}, false},
+		{"wrapped sentinel", fmt.Errorf("upstream: %w", ErrRateLimited), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isUnprocessed(tt.err); got != tt.want {
+				t.Errorf("isUnprocessed(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetry_RetryAfter(t *testing.T) {
+	var calls atomic.Int32
+	var delay time.Duration
+	h := func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"error_code":0,"error_description":"","wallet":"1.00"}`))
+	}
+
+	policy := RetryPolicy{
+		MaxAttempts:    2,
+		InitialBackoff: time.Millisecond,
+		OnRetry: func(e RetryEvent) {
+			delay = e.Delay
+		},
+	}
+	c, closeFn := newTestClient(t, h, WithRetryPolicy(policy))
+	defer closeFn()
+
+	if _, err := c.Balance(context.Background()); err != nil {
+		t.Fatalf("Balance() error: %v", err)
+	}
+	if delay != time.Second {
+		t.Errorf("RetryEvent.Delay = %v, want 1s", delay)
+	}
+}
+
+func TestRetry_RetryAfterBeyondMaxBackoff(t *testing.T) {
+	var calls, retries atomic.Int32
+	h := func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}
+
+	c, closeFn := newTestClient(t, h, WithRetryPolicy(testRetryPolicy(&retries)))
+	defer closeFn()
+
+	_, err := c.Balance(context.Background())
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.RetryAfter != time.Minute {
+		t.Fatalf("Balance() error = %v, want *APIError with RetryAfter 1m", err)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("calls = %d, want 1 when Retry-After exceeds MaxBackoff", n)
+	}
+}
+
+func TestRetry_DisabledByDefault(t *testing.T) {
+	var calls atomic.Int32
+	h := func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}
+
+	c, closeFn := newTestClient(t, h)
+	defer closeFn()
+
+	if _, err := c.Balance(context.Background()); err == nil {
+		t.Fatal("Balance() expected error on 502, got nil")
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("calls = %d, want 1", n)
+	}
+}
@@ -0,0 +1,280 @@
+package salamoontest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"sync"
+)
+
+/*
+A cassette is a JSONL file with one Interaction per line, in the order the
+requests were made:
+
+	{"endpoint":"/createTask","request":{"api_key":"[REDACTED]","task":{...}},"status":200,"response":{"error_code":0,"taskId":"..."}}
+
+The api_key of every request is replaced with RedactedAPIKey before it is
+written. Response bodies that are not JSON are stored as a string in
+response_text instead of response.
+*/
+
+// RedactedAPIKey replaces the api_key of recorded requests.
+const RedactedAPIKey = "[REDACTED]"
+
+// ErrUnmatchedRequest is returned by a Replayer for requests that have no
+// unused interaction in the cassette.
+var ErrUnmatchedRequest = errors.New("salamoontest: no matching interaction in cassette")
+
+type (
+	// Interaction is one recorded request and response.
+	Interaction struct {
+		Endpoint     string          `json:"endpoint"`
+		Request      json.RawMessage `json:"request"`
+		StatusCode   int             `json:"status"`
+		Header       http.Header     `json:"header,omitempty"`
+		Response     json.RawMessage `json:"response,omitempty"`
+		ResponseText string          `json:"response_text,omitempty"`
+	}
+
+	// Recorder is an http.RoundTripper that forwards requests to Transport
+	// and appends every exchange to a cassette.
+	Recorder struct {
+		// Transport sends the requests. Nil means http.DefaultTransport.
+		Transport http.RoundTripper
+
+		mu sync.Mutex
+		f  *os.File
+		w  *bufio.Writer
+	}
+
+	// Replayer is an http.RoundTripper that answers requests from a
+	// cassette without touching the network. Each interaction is used once,
+	// so repeated identical requests replay their responses in order.
+	Replayer struct {
+		mu           sync.Mutex
+		interactions []Interaction
+		used         []bool
+	}
+)
+
+// NewRecorder creates or truncates the cassette at path.
+//
+//	rec, err := salamoontest.NewRecorder("testdata/solve.jsonl", nil)
+//	client, err := salamoonder.New(key, salamoonder.WithHTTPClient(&http.Client{Transport: rec}))
+//	defer rec.Close()
+func NewRecorder(path string, transport http.RoundTripper) (*Recorder, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("salamoontest: create cassette: %w", err)
+	}
+	return &Recorder{Transport: transport, f: f, w: bufio.NewWriter(f)}, nil
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, err := readBody(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	// RoundTrippers must not modify the request, so send a copy carrying
+	// the buffered body.
+	req = req.Clone(req.Context())
+	req.Body = io.NopCloser(bytes.NewReader(reqBody))
+
+	transport := r.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := readBody(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	normalized, err := normalizeRequest(reqBody, true)
+	if err != nil {
+		return nil, err
+	}
+	in := Interaction{
+		Endpoint:   endpointOf(req),
+		Request:    normalized,
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header.Clone(),
+	}
+	in.Header.Del("Date")
+	in.Header.Del("Content-Length")
+	if json.Valid(respBody) {
+		in.Response = compact(respBody)
+	} else {
+		in.ResponseText = string(respBody)
+	}
+
+	line, err := json.Marshal(in)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.w == nil {
+		return nil, errors.New("salamoontest: recorder is closed")
+	}
+	r.w.Write(line)
+	r.w.WriteByte('\n')
+	if err := r.w.Flush(); err != nil {
+		return nil, fmt.Errorf("salamoontest: write cassette: %w", err)
+	}
+	return resp, nil
+}
+
+// Close closes the cassette file.
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.w == nil {
+		return nil
+	}
+	r.w = nil
+	return r.f.Close()
+}
+
+// NewReplayer loads the cassette at path.
+func NewReplayer(path string) (*Replayer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("salamoontest: read cassette: %w", err)
+	}
+
+	r := &Replayer{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	for {
+		var in Interaction
+		if err := dec.Decode(&in); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("salamoontest: parse cassette %s: %w", path, err)
+		}
+		if in.Request, err = normalizeRequest(in.Request, false); err != nil {
+			return nil, err
+		}
+		r.interactions = append(r.interactions, in)
+	}
+	r.used = make([]bool, len(r.interactions))
+	return r, nil
+}
+
+// RoundTrip implements http.RoundTripper. Requests are matched by endpoint
+// and by their JSON body with api_key ignored and keys sorted.
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, err := readBody(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	normalized, err := normalizeRequest(reqBody, false)
+	if err != nil {
+		return nil, err
+	}
+	endpoint := endpointOf(req)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, in := range r.interactions {
+		if r.used[i] || in.Endpoint != endpoint || !bytes.Equal(in.Request, normalized) {
+			continue
+		}
+		r.used[i] = true
+
+		body := []byte(in.Response)
+		if in.Response == nil {
+			body = []byte(in.ResponseText)
+		}
+		header := in.Header.Clone()
+		if header == nil {
+			header = make(http.Header)
+		}
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", in.StatusCode, http.StatusText(in.StatusCode)),
+			StatusCode:    in.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          io.NopCloser(bytes.NewReader(body)),
+			ContentLength: int64(len(body)),
+			Request:       req,
+		}, nil
+	}
+	return nil, fmt.Errorf("%w: %s %s", ErrUnmatchedRequest, endpoint, normalized)
+}
+
+// Unused returns the interactions that have not been replayed yet.
+func (r *Replayer) Unused() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var out []Interaction
+	for i, in := range r.interactions {
+		if !r.used[i] {
+			out = append(out, in)
+		}
+	}
+	return out
+}
+
+// readBody reads and closes body, which may be nil.
+func readBody(body io.ReadCloser) ([]byte, error) {
+	if body == nil {
+		return nil, nil
+	}
+	defer body.Close()
+	return io.ReadAll(body)
+}
+
+// normalizeRequest re-encodes a JSON request body with sorted keys. The
+// api_key is redacted when redact is set and dropped otherwise, so replayed
+// requests match regardless of the key in use.
+func normalizeRequest(body []byte, redact bool) (json.RawMessage, error) {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return json.RawMessage("null"), nil
+	}
+	var v any
+	if err := json.Unmarshal(body, &v); err != nil {
+		return nil, fmt.Errorf("salamoontest: request body is not JSON: %w", err)
+	}
+	if m, ok := v.(map[string]any); ok {
+		if _, ok := m["api_key"]; ok {
+			if redact {
+				m["api_key"] = RedactedAPIKey
+			} else {
+				delete(m, "api_key")
+			}
+		}
+	}
+	return json.Marshal(v)
+}
+
+// endpointOf returns the last path element, which is how the client's
+// endpoint constants are spelled regardless of the base URL.
+func endpointOf(req *http.Request) string {
+	return "/" + path.Base(req.URL.Path)
+}
+
+func compact(data []byte) json.RawMessage {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, data); err != nil {
+		return data
+	}
+	return buf.Bytes()
+}
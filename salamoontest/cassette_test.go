@@ -0,0 +1,105 @@
+package salamoontest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	salamoonder "github.com/juanbrotenelle/go_salamoonder"
+)
+
+func TestCassette_RecordReplay(t *testing.T) {
+	srv := NewServer(t)
+	srv.Script("KasadaCaptchaSolver", Script{
+		PendingPolls: 1,
+		Solution:     salamoonder.KasadaStandardSolution{XKpsdkCt: "ct"},
+	})
+	cassette := filepath.Join(t.TempDir(), "solve.jsonl")
+
+	rec, err := NewRecorder(cassette, nil)
+	if err != nil {
+		t.Fatalf("NewRecorder() error: %v", err)
+	}
+	client := srv.Client(salamoonder.WithHTTPClient(&http.Client{Transport: rec}))
+	if _, err := salamoonder.Solve[salamoonder.KasadaStandardSolution](client, context.Background(), kasadaOptions, fastPoll()...); err != nil {
+		t.Fatalf("Solve() error: %v", err)
+	}
+	if err := rec.Close(); err != nil {
+		t.Fatalf("Close() error: %v", err)
+	}
+
+	data, err := os.ReadFile(cassette)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), APIKey) {
+		t.Errorf("cassette contains the API key:\n%s", data)
+	}
+	if n := strings.Count(string(data), "\n"); n != 3 {
+		t.Errorf("cassette has %d lines, want 3:\n%s", n, data)
+	}
+
+	srv.Close()
+
+	replay, err := NewReplayer(cassette)
+	if err != nil {
+		t.Fatalf("NewReplayer() error: %v", err)
+	}
+	offline, err := salamoonder.New("another-key",
+		salamoonder.WithBaseURL("http://cassette.invalid/api"),
+		salamoonder.WithHTTPClient(&http.Client{Transport: replay}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := salamoonder.Solve[salamoonder.KasadaStandardSolution](offline, context.Background(), kasadaOptions, fastPoll()...)
+	if err != nil {
+		t.Fatalf("replayed Solve() error: %v", err)
+	}
+	if result.Solution.XKpsdkCt != "ct" {
+		t.Errorf("XKpsdkCt = %q, want ct", result.Solution.XKpsdkCt)
+	}
+	if unused := replay.Unused(); len(unused) != 0 {
+		t.Errorf("Unused() = %d interactions, want 0", len(unused))
+	}
+}
+
+func TestCassette_Unmatched(t *testing.T) {
+	cassette := filepath.Join(t.TempDir(), "balance.jsonl")
+	line := `{"endpoint":"/getBalance","request":{"api_key":"[REDACTED]","task":null},"status":200,"response":{"error_code":0,"wallet":"1.00000"}}` + "\n"
+	if err := os.WriteFile(cassette, []byte(line), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	replay, err := NewReplayer(cassette)
+	if err != nil {
+		t.Fatalf("NewReplayer() error: %v", err)
+	}
+	client, err := salamoonder.New("key",
+		salamoonder.WithHTTPClient(&http.Client{Transport: replay}),
+		salamoonder.WithRetryPolicy(salamoonder.RetryPolicy{MaxAttempts: 1}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	balance, err := client.Balance(context.Background())
+	if err != nil {
+		t.Fatalf("Balance() error: %v", err)
+	}
+	if balance.Wallet != "1.00000" {
+		t.Errorf("Wallet = %q, want 1.00000", balance.Wallet)
+	}
+
+	// Each interaction replays once.
+	if _, err := client.Balance(context.Background()); !errors.Is(err, ErrUnmatchedRequest) {
+		t.Errorf("errors.Is(err, ErrUnmatchedRequest) = false, want true; err = %v", err)
+	}
+	if _, err := client.Task(context.Background(), "task-1"); !errors.Is(err, ErrUnmatchedRequest) {
+		t.Errorf("errors.Is(err, ErrUnmatchedRequest) = false, want true; err = %v", err)
+	}
+}
@@ -0,0 +1,457 @@
+/*
+Package salamoontest provides an in-memory fake of the Salamoonder API for
+tests of code built on the salamoonder client.
+
+	srv := salamoontest.NewServer(t, salamoontest.WithBalance("10.00"))
+	srv.Script("KasadaCaptchaSolver", salamoontest.Script{
+		PendingPolls: 2,
+		Solution:     salamoonder.KasadaStandardSolution{XKpsdkCt: "ct"},
+	})
+
+	client := srv.Client()
+	result, err := salamoonder.Solve[salamoonder.KasadaStandardSolution](client, ctx, opts)
+*/
+package salamoontest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	salamoonder "github.com/juanbrotenelle/go_salamoonder"
+)
+
+// APIKey is the key accepted by a Server unless WithAPIKey is used.
+const APIKey = "sr-test-key"
+
+type (
+	// Server is a fake Salamoonder API backed by httptest.Server.
+	Server struct {
+		// URL is the base URL of the server, for salamoonder.WithBaseURL.
+		URL string
+
+		srv *httptest.Server
+
+		mu           sync.Mutex
+		apiKey       string
+		balance      salamoonder.Balance
+		defaultPrice salamoonder.Balance
+		pendingPolls int
+		scripts      map[string]Script
+		tasks        map[string]*task
+		nextId       int
+		faults       []Fault
+		requests     []Request
+	}
+
+	// Option configures a Server.
+	Option func(*Server)
+
+	// Script programs the lifecycle of tasks of one task type.
+	Script struct {
+		// PendingPolls is the number of /getTaskResult calls answered with
+		// "pending" before the task settles. Negative values keep the task
+		// pending forever.
+		PendingPolls int
+		// Fail makes the task end as failed instead of ready.
+		Fail bool
+		// Solution is marshaled as the solution of ready tasks.
+		Solution any
+		// Price is the amount deducted from the balance per created task,
+		// e.g. "0.0015". Empty uses the server's default price.
+		Price string
+	}
+
+	// Fault is an error the server returns instead of handling a request.
+	Fault struct {
+		// Endpoint restricts the fault to one endpoint; empty matches all.
+		Endpoint string
+		// StatusCode is the HTTP status to answer with. Zero means 200.
+		StatusCode int
+		// ErrorCode, if set, is reported in-band as error_code (or errorId
+		// for /getTaskResult) together with Message.
+		ErrorCode int
+		Message   string
+		// Body overrides the response body.
+		Body string
+		// Header is added to the response, e.g. Retry-After.
+		Header http.Header
+		// Latency delays the response, or ends it without one if the request
+		// is canceled first.
+		Latency time.Duration
+		// Times is how many requests the fault applies to. Zero means once.
+		Times int
+	}
+
+	// Request is a request received by the server.
+	Request struct {
+		Endpoint string
+		APIKey   string
+		// TaskType and Task are set for /createTask.
+		TaskType string
+		Task     map[string]any
+		// TaskId is set for /getTaskResult.
+		TaskId string
+	}
+
+	// Task is the state of a task created on the server.
+	Task struct {
+		Id       string
+		Type     string
+		Options  map[string]any
+		Polls    int
+		Status   salamoonder.TaskStatus
+		Solution json.RawMessage
+	}
+
+	task struct {
+		Task
+		script Script
+	}
+)
+
+// WithAPIKey sets the only API key the server accepts.
+func WithAPIKey(key string) Option {
+	return func(s *Server) {
+		s.apiKey = key
+	}
+}
+
+// WithBalance sets the initial wallet, e.g. "499.81070".
+func WithBalance(amount string) Option {
+	return func(s *Server) {
+		s.balance = mustParseAmount(amount)
+	}
+}
+
+// WithDefaultPrice sets the price of tasks whose Script has none.
+func WithDefaultPrice(amount string) Option {
+	return func(s *Server) {
+		s.defaultPrice = mustParseAmount(amount)
+	}
+}
+
+// WithPendingPolls sets PendingPolls for task types without a Script.
+func WithPendingPolls(n int) Option {
+	return func(s *Server) {
+		s.pendingPolls = n
+	}
+}
+
+// NewServer starts a server that is closed when tb finishes. It starts with
+// a balance of 100 and charges 0.001 per task.
+func NewServer(tb testing.TB, opts ...Option) *Server {
+	tb.Helper()
+
+	s := &Server{
+		apiKey:       APIKey,
+		balance:      mustParseAmount("100"),
+		defaultPrice: mustParseAmount("0.001"),
+		scripts:      make(map[string]Script),
+		tasks:        make(map[string]*task),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	s.srv = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	s.URL = s.srv.URL
+	tb.Cleanup(s.Close)
+	return s
+}
+
+// Close shuts the server down.
+func (s *Server) Close() {
+	s.srv.Close()
+}
+
+// Client returns a client using the server's API key and URL.
+func (s *Server) Client(opts ...salamoonder.Option) *salamoonder.Client {
+	s.mu.Lock()
+	key := s.apiKey
+	s.mu.Unlock()
+
+	c, err := salamoonder.New(key, append([]salamoonder.Option{salamoonder.WithBaseURL(s.URL)}, opts...)...)
+	if err != nil {
+		panic(fmt.Sprintf("salamoontest: %v", err))
+	}
+	return c
+}
+
+// Script programs tasks of taskType created from now on.
+func (s *Server) Script(taskType string, script Script) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.scripts[taskType] = script
+}
+
+// InjectFault queues faults. Faults are consumed in order by matching
+// requests.
+func (s *Server) InjectFault(faults ...Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.faults = append(s.faults, faults...)
+}
+
+// Balance returns the current wallet, formatted like the API does.
+func (s *Server) Balance() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return formatAmount(s.balance)
+}
+
+// SetBalance replaces the wallet.
+func (s *Server) SetBalance(amount string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.balance = mustParseAmount(amount)
+}
+
+// Requests returns the requests received so far, optionally only those to
+// endpoint.
+func (s *Server) Requests(endpoint ...string) []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var out []Request
+	for _, r := range s.requests {
+		if len(endpoint) == 0 || r.Endpoint == endpoint[0] {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// Task returns the state of a created task.
+func (s *Server) Task(taskId string) (Task, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.tasks[taskId]
+	if !ok {
+		return Task{}, false
+	}
+	return t.Task, true
+}
+
+// AssertRequests fails tb unless the server received want requests to
+// endpoint.
+func (s *Server) AssertRequests(tb testing.TB, endpoint string, want int) {
+	tb.Helper()
+	if got := len(s.Requests(endpoint)); got != want {
+		tb.Errorf("salamoontest: %s received %d requests, want %d", endpoint, got, want)
+	}
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		APIKey string         `json:"api_key"`
+		Task   map[string]any `json:"task"`
+		TaskId string         `json:"taskId"`
+	}
+	if r.Method != http.MethodPost || json.NewDecoder(r.Body).Decode(&body) != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error_code": 1, "error_description": "invalid request"})
+		return
+	}
+
+	req := Request{
+		Endpoint: r.URL.Path,
+		APIKey:   body.APIKey,
+		Task:     body.Task,
+		TaskId:   body.TaskId,
+	}
+	if body.Task != nil {
+		req.TaskType, _ = body.Task["type"].(string)
+	}
+
+	s.mu.Lock()
+	s.requests = append(s.requests, req)
+	fault, faulted := s.takeFault(req.Endpoint)
+	s.mu.Unlock()
+
+	if faulted {
+		s.writeFault(w, r, req.Endpoint, fault)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if req.APIKey != s.apiKey {
+		writeJSON(w, http.StatusUnauthorized, map[string]any{"error_code": 1, "error_description": "invalid api key"})
+		return
+	}
+
+	switch req.Endpoint {
+	case salamoonder.EndpointGetBalance:
+		writeJSON(w, http.StatusOK, map[string]any{"error_code": 0, "error_description": "", "wallet": formatAmount(s.balance)})
+	case salamoonder.EndpointCreateTask:
+		s.createTask(w, req)
+	case salamoonder.EndpointGetTaskResult:
+		s.getTaskResult(w, req)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Server) createTask(w http.ResponseWriter, req Request) {
+	if req.TaskType == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error_code": 1, "error_description": "missing task type"})
+		return
+	}
+
+	script, ok := s.scripts[req.TaskType]
+	if !ok {
+		script = Script{PendingPolls: s.pendingPolls}
+	}
+	price := s.defaultPrice
+	if script.Price != "" {
+		price = mustParseAmount(script.Price)
+	}
+	if s.balance.LessThan(price) {
+		writeJSON(w, http.StatusOK, map[string]any{"error_code": 1, "error_description": "insufficient funds", "taskId": ""})
+		return
+	}
+	s.balance = s.balance.Sub(price)
+
+	s.nextId++
+	t := &task{
+		Task: Task{
+			Id:      fmt.Sprintf("task-%d", s.nextId),
+			Type:    req.TaskType,
+			Options: req.Task,
+			Status:  salamoonder.TaskStatusPending,
+		},
+		script: script,
+	}
+	s.tasks[t.Id] = t
+	writeJSON(w, http.StatusOK, map[string]any{"error_code": 0, "error_description": "", "taskId": t.Id})
+}
+
+func (s *Server) getTaskResult(w http.ResponseWriter, req Request) {
+	t, ok := s.tasks[req.TaskId]
+	if !ok {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error_code": 1, "error_description": "task not found"})
+		return
+	}
+
+	t.Polls++
+	if !t.Status.IsTerminal() && t.script.PendingPolls >= 0 && t.Polls > t.script.PendingPolls {
+		if t.script.Fail {
+			t.Status = salamoonder.TaskStatusFailed
+		} else {
+			t.Status = salamoonder.TaskStatusReady
+			t.Solution = marshalSolution(t.script.Solution)
+		}
+	}
+
+	switch t.Status {
+	case salamoonder.TaskStatusFailed:
+		writeJSON(w, http.StatusOK, map[string]any{"errorId": 1, "status": t.Status, "solution": nil})
+	case salamoonder.TaskStatusReady:
+		writeJSON(w, http.StatusOK, map[string]any{"errorId": 0, "status": t.Status, "solution": t.Solution})
+	default:
+		writeJSON(w, http.StatusOK, map[string]any{"errorId": 0, "status": t.Status, "solution": nil})
+	}
+}
+
+// takeFault pops the first fault matching endpoint. s.mu must be held.
+func (s *Server) takeFault(endpoint string) (Fault, bool) {
+	for i, f := range s.faults {
+		if f.Endpoint != "" && f.Endpoint != endpoint {
+			continue
+		}
+		if f.Times <= 1 {
+			s.faults = append(s.faults[:i], s.faults[i+1:]...)
+		} else {
+			s.faults[i].Times--
+		}
+		return f, true
+	}
+	return Fault{}, false
+}
+
+func (s *Server) writeFault(w http.ResponseWriter, r *http.Request, endpoint string, f Fault) {
+	if f.Latency > 0 {
+		timer := time.NewTimer(f.Latency)
+		select {
+		case <-r.Context().Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+	for k, v := range f.Header {
+		w.Header()[k] = v
+	}
+
+	status := f.StatusCode
+	if status == 0 {
+		status = http.StatusOK
+	}
+	if f.Body != "" {
+		w.WriteHeader(status)
+		w.Write([]byte(f.Body))
+		return
+	}
+
+	code := f.ErrorCode
+	if code == 0 && status != http.StatusOK {
+		code = 1
+	}
+	msg := f.Message
+	if msg == "" {
+		msg = "injected fault"
+	}
+	if endpoint == salamoonder.EndpointGetTaskResult && status == http.StatusOK {
+		writeJSON(w, status, map[string]any{"errorId": code, "status": salamoonder.TaskStatusFailed, "error_description": msg, "solution": nil})
+		return
+	}
+	writeJSON(w, status, map[string]any{"error_code": code, "error_description": msg})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func marshalSolution(v any) json.RawMessage {
+	if v == nil {
+		return json.RawMessage(`{}`)
+	}
+	if raw, ok := v.(json.RawMessage); ok {
+		return raw
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic(fmt.Sprintf("salamoontest: marshal solution: %v", err))
+	}
+	return data
+}
+
+func mustParseAmount(s string) salamoonder.Balance {
+	b, err := salamoonder.ParseBalance(s)
+	if err != nil || b.Sign() < 0 {
+		panic(fmt.Sprintf("salamoontest: invalid amount %q", s))
+	}
+	return b
+}
+
+// formatAmount formats b with at least 5 decimal places, like the API.
+func formatAmount(b salamoonder.Balance) string {
+	whole, frac, _ := strings.Cut(b.String(), ".")
+	if len(frac) < 5 {
+		frac += strings.Repeat("0", 5-len(frac))
+	}
+	return whole + "." + frac
+}
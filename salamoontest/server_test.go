@@ -0,0 +1,191 @@
+package salamoontest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	salamoonder "github.com/juanbrotenelle/go_salamoonder"
+)
+
+var kasadaOptions = salamoonder.KasadaStandardOptions{Pjs: "https://example.com/p.js"}
+
+func fastPoll() []salamoonder.PollOption {
+	return []salamoonder.PollOption{salamoonder.WithPollInterval(time.Millisecond)}
+}
+
+func TestServer_SolveLifecycle(t *testing.T) {
+	srv := NewServer(t, WithBalance("1.00"))
+	srv.Script("KasadaCaptchaSolver", Script{
+		PendingPolls: 2,
+		Solution:     salamoonder.KasadaStandardSolution{XKpsdkCt: "ct", UserAgent: "UA"},
+		Price:        "0.25",
+	})
+	client := srv.Client()
+
+	result, err := salamoonder.Solve[salamoonder.KasadaStandardSolution](client, context.Background(), kasadaOptions, fastPoll()...)
+	if err != nil {
+		t.Fatalf("Solve() error: %v", err)
+	}
+	if result.Solution.XKpsdkCt != "ct" || result.Solution.UserAgent != "UA" {
+		t.Errorf("Solution = %+v, want scripted solution", result.Solution)
+	}
+
+	srv.AssertRequests(t, salamoonder.EndpointCreateTask, 1)
+	srv.AssertRequests(t, salamoonder.EndpointGetTaskResult, 3)
+
+	created := srv.Requests(salamoonder.EndpointCreateTask)[0]
+	if created.TaskType != "KasadaCaptchaSolver" || created.Task["pjs"] != kasadaOptions.Pjs {
+		t.Errorf("createTask request = %+v, want type and pjs", created)
+	}
+	if created.APIKey != APIKey {
+		t.Errorf("APIKey = %q, want %q", created.APIKey, APIKey)
+	}
+
+	if got := srv.Balance(); got != "0.75000" {
+		t.Errorf("Balance() = %q, want 0.75000", got)
+	}
+	balance, err := client.Balance(context.Background())
+	if err != nil {
+		t.Fatalf("Balance() error: %v", err)
+	}
+	if balance.Wallet != "0.75000" {
+		t.Errorf("Wallet = %q, want 0.75000", balance.Wallet)
+	}
+}
+
+func TestServer_ScriptedFailure(t *testing.T) {
+	srv := NewServer(t)
+	srv.Script("KasadaCaptchaSolver", Script{PendingPolls: 1, Fail: true})
+
+	_, err := salamoonder.Solve[salamoonder.KasadaStandardSolution](srv.Client(), context.Background(), kasadaOptions, fastPoll()...)
+	if !errors.Is(err, salamoonder.ErrTaskFailed) {
+		t.Fatalf("errors.Is(err, ErrTaskFailed) = false, want true; err = %v", err)
+	}
+
+	task, ok := srv.Task("task-1")
+	if !ok || task.Status != salamoonder.TaskStatusFailed || task.Polls != 2 {
+		t.Errorf("Task() = %+v, %v; want failed after 2 polls", task, ok)
+	}
+}
+
+func TestServer_InsufficientBalance(t *testing.T) {
+	srv := NewServer(t, WithBalance("0.001"), WithDefaultPrice("0.002"))
+
+	_, err := srv.Client().CreateTask(context.Background(), kasadaOptions)
+	if !errors.Is(err, salamoonder.ErrInsufficientBalance) {
+		t.Fatalf("errors.Is(err, ErrInsufficientBalance) = false, want true; err = %v", err)
+	}
+	if got := srv.Balance(); got != "0.00100" {
+		t.Errorf("Balance() = %q, want unchanged", got)
+	}
+}
+
+func TestServer_InvalidAPIKey(t *testing.T) {
+	srv := NewServer(t, WithAPIKey("secret"))
+	client, err := salamoonder.New("wrong", salamoonder.WithBaseURL(srv.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = client.Balance(context.Background())
+	if !errors.Is(err, salamoonder.ErrInvalidAPIKey) {
+		t.Fatalf("errors.Is(err, ErrInvalidAPIKey) = false, want true; err = %v", err)
+	}
+}
+
+func TestServer_TaskNotFound(t *testing.T) {
+	srv := NewServer(t)
+
+	_, err := srv.Client().Task(context.Background(), "missing")
+	if !errors.Is(err, salamoonder.ErrTaskNotFound) {
+		t.Fatalf("errors.Is(err, ErrTaskNotFound) = false, want true; err = %v", err)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Faults
+// ---------------------------------------------------------------------------
+
+func TestServer_Faults(t *testing.T) {
+	tests := []struct {
+		name       string
+		fault      Fault
+		wantStatus int
+		wantKind   error
+	}{
+		{"bad request", Fault{StatusCode: http.StatusBadRequest, Message: "bad request"}, http.StatusBadRequest, nil},
+		{"in-band", Fault{ErrorCode: 1, Message: "insufficient funds"}, http.StatusOK, salamoonder.ErrInsufficientBalance},
+		{"server error", Fault{StatusCode: http.StatusBadGateway}, http.StatusBadGateway, nil},
+		{"rate limited", Fault{StatusCode: http.StatusTooManyRequests}, http.StatusTooManyRequests, salamoonder.ErrRateLimited},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := NewServer(t)
+			tt.fault.Endpoint = salamoonder.EndpointGetBalance
+			srv.InjectFault(tt.fault)
+
+			_, err := srv.Client(salamoonder.WithRetryPolicy(salamoonder.RetryPolicy{MaxAttempts: 1})).Balance(context.Background())
+			var apiErr *salamoonder.APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("Balance() error = %v, want *APIError", err)
+			}
+			if apiErr.StatusCode != tt.wantStatus {
+				t.Errorf("StatusCode = %d, want %d", apiErr.StatusCode, tt.wantStatus)
+			}
+			if tt.wantKind != nil && !errors.Is(err, tt.wantKind) {
+				t.Errorf("errors.Is(err, %v) = false, want true; err = %v", tt.wantKind, err)
+			}
+		})
+	}
+}
+
+func TestServer_FaultRetried(t *testing.T) {
+	srv := NewServer(t)
+	srv.InjectFault(Fault{Endpoint: salamoonder.EndpointGetBalance, StatusCode: http.StatusServiceUnavailable, Times: 2})
+
+	client := srv.Client(salamoonder.WithRetryPolicy(salamoonder.RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}))
+	if _, err := client.Balance(context.Background()); err != nil {
+		t.Fatalf("Balance() error: %v", err)
+	}
+	srv.AssertRequests(t, salamoonder.EndpointGetBalance, 3)
+}
+
+func TestServer_FaultLatency(t *testing.T) {
+	srv := NewServer(t)
+	srv.InjectFault(Fault{Endpoint: salamoonder.EndpointGetBalance, Latency: time.Minute, Body: `{"error_code":0,"wallet":"1.00000"}`})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := srv.Client(salamoonder.WithRetryPolicy(salamoonder.RetryPolicy{MaxAttempts: 1})).Balance(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("errors.Is(err, context.DeadlineExceeded) = false, want true; err = %v", err)
+	}
+
+	// The handler stops waiting once the client is gone, so Close does not
+	// block for the rest of the latency.
+	start := time.Now()
+	srv.Close()
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("Close() took %v, want the delayed handler to return early", d)
+	}
+}
+
+func TestServer_FaultEndpointFilter(t *testing.T) {
+	srv := NewServer(t)
+	srv.InjectFault(Fault{Endpoint: salamoonder.EndpointCreateTask, StatusCode: http.StatusInternalServerError})
+
+	client := srv.Client(salamoonder.WithRetryPolicy(salamoonder.RetryPolicy{MaxAttempts: 1}))
+	if _, err := client.Balance(context.Background()); err != nil {
+		t.Fatalf("Balance() error: %v", err)
+	}
+	if _, err := client.CreateTask(context.Background(), kasadaOptions); err == nil {
+		t.Fatal("CreateTask() error = nil, want injected fault")
+	}
+	if _, err := client.CreateTask(context.Background(), kasadaOptions); err != nil {
+		t.Fatalf("CreateTask() error after fault consumed: %v", err)
+	}
+}
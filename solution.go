@@ -0,0 +1,366 @@
+package salamoonder
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Solution types keep the JSON fields they do not declare in Extra, so that
+// fields the API adds are not lost when a solution is stored and marshaled
+// again. With WithStrictDecoding such fields are errors instead.
+
+// knownFieldsCache maps a struct type to the lower-cased JSON names of its
+// fields.
+var knownFieldsCache sync.Map
+
+// WithStrictDecoding fails Solve, GetTaskResult and Future results with
+// ErrUnknownFields when a solution has fields its type does not declare,
+// e.g. so that tests notice when the API changes.
+func WithStrictDecoding() Option {
+	return func(c *client) {
+		c.strictDecoding = true
+	}
+}
+
+// DecodeSolution decodes the solution into the solution type registered
+// under the task type name. Unknown fields are kept in Extra. A task type
+// whose solution depends on the options, like IncapsulaReese84Solver with
+// SubmitPayload, decodes into its default type; use DecodeSolutionFor.
+func (r *TaskResultRaw) DecodeSolution(name string) (any, error) {
+	return r.decodeSolution(name, false)
+}
+
+// DecodeSolutionStrict is DecodeSolution, but fails with ErrUnknownFields
+// if the solution has fields its type does not declare.
+func (r *TaskResultRaw) DecodeSolutionStrict(name string) (any, error) {
+	return r.decodeSolution(name, true)
+}
+
+// DecodeSolutionFor decodes the solution into the solution type of a task
+// created with options, including types chosen by RegisterSolutionType.
+// Unknown fields are kept in Extra.
+func (r *TaskResultRaw) DecodeSolutionFor(options any) (any, error) {
+	return r.decodeSolutionFor(options, false)
+}
+
+// DecodeSolutionForStrict is DecodeSolutionFor, but fails with
+// ErrUnknownFields if the solution has fields its type does not declare.
+func (r *TaskResultRaw) DecodeSolutionForStrict(options any) (any, error) {
+	return r.decodeSolutionFor(options, true)
+}
+
+func (r *TaskResultRaw) decodeSolution(name string, strict bool) (any, error) {
+	t := registry.lookupName(name)
+	if t == nil {
+		return nil, fmt.Errorf("%w: unknown task type %q; registered: %v", ErrUnsupportedTaskOptionsType, name, TaskTypes())
+	}
+	return r.decodeSolutionInto(t.name, t.solutionType, strict)
+}
+
+func (r *TaskResultRaw) decodeSolutionFor(options any, strict bool) (any, error) {
+	t, solutionType := registry.solutionTypeFor(options)
+	if t == nil {
+		return nil, &MethodError{OptionsValue: options}
+	}
+	return r.decodeSolutionInto(t.name, solutionType, strict)
+}
+
+func (r *TaskResultRaw) decodeSolutionInto(name string, solutionType reflect.Type, strict bool) (any, error) {
+	ptr := reflect.New(solutionType)
+	if err := json.Unmarshal(r.Solution, ptr.Interface()); err != nil {
+		return nil, fmt.Errorf("decode %s solution: %w", name, err)
+	}
+	solution := ptr.Elem().Interface()
+	if strict {
+		if err := checkUnknownFields(solution); err != nil {
+			return nil, err
+		}
+	}
+	return solution, nil
+}
+
+// checkUnknownFields fails with ErrUnknownFields if solution is a struct
+// with a non-empty Extra field, or has one in a struct nested in it.
+func checkUnknownFields(solution any) error {
+	v := reflect.Indirect(reflect.ValueOf(solution))
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+	var names []string
+	collectUnknownFields(v, "", &names)
+	if len(names) == 0 {
+		return nil
+	}
+	sort.Strings(names)
+	return fmt.Errorf("%w: %s has %s", ErrUnknownFields, v.Type().Name(), strings.Join(names, ", "))
+}
+
+// collectUnknownFields appends the names in the Extra fields of v and of the
+// structs nested in it, as dotted JSON paths below prefix.
+func collectUnknownFields(v reflect.Value, prefix string, names *[]string) {
+	t := v.Type()
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		fv := v.Field(i)
+		if f.Name == "Extra" {
+			extra, _ := fv.Interface().(map[string]json.RawMessage)
+			for name := range extra {
+				*names = append(*names, prefix+name)
+			}
+			continue
+		}
+		name, ok := jsonName(f)
+		if fv = reflect.Indirect(fv); ok && fv.Kind() == reflect.Struct {
+			collectUnknownFields(fv, prefix+name+".", names)
+		}
+	}
+}
+
+// checkUnknownFields is called on decoded responses with
+// WithStrictDecoding.
+func (r *TaskResult[TS]) checkUnknownFields() error {
+	return checkUnknownFields(r.Solution)
+}
+
+// unmarshalSolution decodes data into v, a pointer to a solution struct
+// without its methods, and stores the fields v does not declare in extra.
+func unmarshalSolution(data []byte, v any, extra *map[string]json.RawMessage) error {
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		return nil
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+
+	known := knownFields(reflect.TypeOf(v).Elem())
+	for name := range fields {
+		if known[strings.ToLower(name)] {
+			delete(fields, name)
+		}
+	}
+	if len(fields) == 0 {
+		fields = nil
+	}
+	*extra = fields
+	return nil
+}
+
+// marshalSolution encodes v, a solution struct without its methods, and
+// appends the fields in extra that v does not declare, sorted by name.
+func marshalSolution(v any, extra map[string]json.RawMessage) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil || len(extra) == 0 {
+		return data, err
+	}
+
+	known := knownFields(reflect.TypeOf(v))
+	names := make([]string, 0, len(extra))
+	for name := range extra {
+		if !known[strings.ToLower(name)] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	buf.Write(data[:len(data)-1])
+	for _, name := range names {
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(name)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(extra[name])
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// knownFields returns the JSON names of t's fields, lower-cased because
+// encoding/json matches them case-insensitively.
+func knownFields(t reflect.Type) map[string]bool {
+	if known, ok := knownFieldsCache.Load(t); ok {
+		return known.(map[string]bool)
+	}
+
+	known := make(map[string]bool, t.NumField())
+	for i := range t.NumField() {
+		if name, ok := jsonName(t.Field(i)); ok {
+			known[strings.ToLower(name)] = true
+		}
+	}
+	knownFieldsCache.Store(t, known)
+	return known
+}
+
+// jsonName returns the name encoding/json uses for f, and false if f is not
+// encoded.
+func jsonName(f reflect.StructField) (string, bool) {
+	if !f.IsExported() {
+		return "", false
+	}
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	switch name {
+	case "-":
+		return "", false
+	case "":
+		return f.Name, true
+	}
+	return name, true
+}
+
+func (s *KasadaStandardSolution) UnmarshalJSON(data []byte) error {
+	type plain KasadaStandardSolution
+	return unmarshalSolution(data, (*plain)(s), &s.Extra)
+}
+
+func (s KasadaStandardSolution) MarshalJSON() ([]byte, error) {
+	type plain KasadaStandardSolution
+	return marshalSolution(plain(s), s.Extra)
+}
+
+func (s *KasadaPayloadSolution) UnmarshalJSON(data []byte) error {
+	type plain KasadaPayloadSolution
+	return unmarshalSolution(data, (*plain)(s), &s.Extra)
+}
+
+func (s KasadaPayloadSolution) MarshalJSON() ([]byte, error) {
+	type plain KasadaPayloadSolution
+	return marshalSolution(plain(s), s.Extra)
+}
+
+func (h *KasadaPayloadHeaders) UnmarshalJSON(data []byte) error {
+	type plain KasadaPayloadHeaders
+	return unmarshalSolution(data, (*plain)(h), &h.Extra)
+}
+
+func (h KasadaPayloadHeaders) MarshalJSON() ([]byte, error) {
+	type plain KasadaPayloadHeaders
+	return marshalSolution(plain(h), h.Extra)
+}
+
+func (s *AkamaiWebSolution) UnmarshalJSON(data []byte) error {
+	type plain AkamaiWebSolution
+	return unmarshalSolution(data, (*plain)(s), &s.Extra)
+}
+
+func (s AkamaiWebSolution) MarshalJSON() ([]byte, error) {
+	type plain AkamaiWebSolution
+	return marshalSolution(plain(s), s.Extra)
+}
+
+func (s *AkamaiSBSDSolution) UnmarshalJSON(data []byte) error {
+	type plain AkamaiSBSDSolution
+	return unmarshalSolution(data, (*plain)(s), &s.Extra)
+}
+
+func (s AkamaiSBSDSolution) MarshalJSON() ([]byte, error) {
+	type plain AkamaiSBSDSolution
+	return marshalSolution(plain(s), s.Extra)
+}
+
+func (s *Reese84SubmitPayloadSolution) UnmarshalJSON(data []byte) error {
+	type plain Reese84SubmitPayloadSolution
+	return unmarshalSolution(data, (*plain)(s), &s.Extra)
+}
+
+func (s Reese84SubmitPayloadSolution) MarshalJSON() ([]byte, error) {
+	type plain Reese84SubmitPayloadSolution
+	return marshalSolution(plain(s), s.Extra)
+}
+
+func (s *Reese84Solution) UnmarshalJSON(data []byte) error {
+	type plain Reese84Solution
+	return unmarshalSolution(data, (*plain)(s), &s.Extra)
+}
+
+func (s Reese84Solution) MarshalJSON() ([]byte, error) {
+	type plain Reese84Solution
+	return marshalSolution(plain(s), s.Extra)
+}
+
+func (s *UutmvcSolution) UnmarshalJSON(data []byte) error {
+	type plain UutmvcSolution
+	return unmarshalSolution(data, (*plain)(s), &s.Extra)
+}
+
+func (s UutmvcSolution) MarshalJSON() ([]byte, error) {
+	type plain UutmvcSolution
+	return marshalSolution(plain(s), s.Extra)
+}
+
+func (s *TwitchScraperSolution) UnmarshalJSON(data []byte) error {
+	type plain TwitchScraperSolution
+	return unmarshalSolution(data, (*plain)(s), &s.Extra)
+}
+
+func (s TwitchScraperSolution) MarshalJSON() ([]byte, error) {
+	type plain TwitchScraperSolution
+	return marshalSolution(plain(s), s.Extra)
+}
+
+func (s *TwitchPublicIntegritySolution) UnmarshalJSON(data []byte) error {
+	type plain TwitchPublicIntegritySolution
+	return unmarshalSolution(data, (*plain)(s), &s.Extra)
+}
+
+func (s TwitchPublicIntegritySolution) MarshalJSON() ([]byte, error) {
+	type plain TwitchPublicIntegritySolution
+	return marshalSolution(plain(s), s.Extra)
+}
+
+func (s *TwitchLocalIntegritySolution) UnmarshalJSON(data []byte) error {
+	type plain TwitchLocalIntegritySolution
+	return unmarshalSolution(data, (*plain)(s), &s.Extra)
+}
+
+func (s TwitchLocalIntegritySolution) MarshalJSON() ([]byte, error) {
+	type plain TwitchLocalIntegritySolution
+	return marshalSolution(plain(s), s.Extra)
+}
+
+func (s *TwitchIntegritySolution) UnmarshalJSON(data []byte) error {
+	type plain TwitchIntegritySolution
+	return unmarshalSolution(data, (*plain)(s), &s.Extra)
+}
+
+func (s TwitchIntegritySolution) MarshalJSON() ([]byte, error) {
+	type plain TwitchIntegritySolution
+	return marshalSolution(plain(s), s.Extra)
+}
+
+func (s *DataDomeInterstitialSolution) UnmarshalJSON(data []byte) error {
+	type plain DataDomeInterstitialSolution
+	return unmarshalSolution(data, (*plain)(s), &s.Extra)
+}
+
+func (s DataDomeInterstitialSolution) MarshalJSON() ([]byte, error) {
+	type plain DataDomeInterstitialSolution
+	return marshalSolution(plain(s), s.Extra)
+}
+
+func (s *DataDomeSliderSolution) UnmarshalJSON(data []byte) error {
+	type plain DataDomeSliderSolution
+	return unmarshalSolution(data, (*plain)(s), &s.Extra)
+}
+
+func (s DataDomeSliderSolution) MarshalJSON() ([]byte, error) {
+	type plain DataDomeSliderSolution
+	return marshalSolution(plain(s), s.Extra)
+}
@@ -0,0 +1,220 @@
+package salamoonder
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSolution_ExtraRoundTrip(t *testing.T) {
+	data := []byte(`{"user-agent":"UA","x-kpsdk-ct":"ct","x-new":1,"nested":{"a":[1,2]}}`)
+
+	var s KasadaStandardSolution
+	if err := json.Unmarshal(data, &s); err != nil {
+		t.Fatalf("Unmarshal() error: %v", err)
+	}
+	if s.UserAgent != "UA" || s.XKpsdkCt != "ct" {
+		t.Errorf("known fields = %+v, want them decoded", s)
+	}
+	want := map[string]json.RawMessage{
+		"x-new":  json.RawMessage(`1`),
+		"nested": json.RawMessage(`{"a":[1,2]}`),
+	}
+	if !reflect.DeepEqual(s.Extra, want) {
+		t.Errorf("Extra = %s, want %s", s.Extra, want)
+	}
+
+	out, err := json.Marshal(s)
+	if err != nil {
+		t.Fatalf("Marshal() error: %v", err)
+	}
+	var again KasadaStandardSolution
+	if err := json.Unmarshal(out, &again); err != nil {
+		t.Fatalf("Unmarshal() error: %v", err)
+	}
+	if !reflect.DeepEqual(again, s) {
+		t.Errorf("round trip = %+v, want %+v", again, s)
+	}
+}
+
+func TestSolution_NoExtra(t *testing.T) {
+	// encoding/json matches field names case-insensitively.
+	var s KasadaStandardSolution
+	if err := json.Unmarshal([]byte(`{"User-Agent":"UA"}`), &s); err != nil {
+		t.Fatalf("Unmarshal() error: %v", err)
+	}
+	if s.UserAgent != "UA" || s.Extra != nil {
+		t.Errorf("solution = %+v, want UserAgent set and no Extra", s)
+	}
+
+	out, err := json.Marshal(DataDomeSliderSolution{Cookie: "c"})
+	if err != nil {
+		t.Fatalf("Marshal() error: %v", err)
+	}
+	if want := `{"cookie":"c","user-agent":""}`; string(out) != want {
+		t.Errorf("Marshal() = %s, want %s", out, want)
+	}
+}
+
+func TestSolution_AllTypesKeepExtra(t *testing.T) {
+	types := []reflect.Type{
+		reflect.TypeFor[KasadaStandardSolution](),
+		reflect.TypeFor[KasadaPayloadSolution](),
+		reflect.TypeFor[AkamaiWebSolution](),
+		reflect.TypeFor[AkamaiSBSDSolution](),
+		reflect.TypeFor[Reese84SubmitPayloadSolution](),
+		reflect.TypeFor[Reese84Solution](),
+		reflect.TypeFor[UutmvcSolution](),
+		reflect.TypeFor[TwitchScraperSolution](),
+		reflect.TypeFor[TwitchPublicIntegritySolution](),
+		reflect.TypeFor[TwitchLocalIntegritySolution](),
+		reflect.TypeFor[TwitchIntegritySolution](),
+		reflect.TypeFor[DataDomeInterstitialSolution](),
+		reflect.TypeFor[DataDomeSliderSolution](),
+	}
+
+	for _, typ := range types {
+		t.Run(typ.Name(), func(t *testing.T) {
+			ptr := reflect.New(typ)
+			if err := json.Unmarshal([]byte(`{"brand-new":"x"}`), ptr.Interface()); err != nil {
+				t.Fatalf("Unmarshal() error: %v", err)
+			}
+			out, err := json.Marshal(ptr.Elem().Interface())
+			if err != nil {
+				t.Fatalf("Marshal() error: %v", err)
+			}
+			var fields map[string]any
+			if err := json.Unmarshal(out, &fields); err != nil {
+				t.Fatalf("Unmarshal() error: %v", err)
+			}
+			if fields["brand-new"] != "x" {
+				t.Errorf("Marshal() = %s, want brand-new kept", out)
+			}
+		})
+	}
+}
+
+func TestSolution_NestedExtra(t *testing.T) {
+	data := []byte(`{"headers":{"x-kpsdk-ct":"ct","x-kpsdk-new":"n"},"payload":"p"}`)
+
+	var s KasadaPayloadSolution
+	if err := json.Unmarshal(data, &s); err != nil {
+		t.Fatalf("Unmarshal() error: %v", err)
+	}
+	if s.Headers.XKpsdkCt != "ct" || string(s.Headers.Extra["x-kpsdk-new"]) != `"n"` || s.Extra != nil {
+		t.Errorf("solution = %+v, want the unknown header in Headers.Extra", s)
+	}
+
+	out, err := json.Marshal(s)
+	if err != nil {
+		t.Fatalf("Marshal() error: %v", err)
+	}
+	var again KasadaPayloadSolution
+	if err := json.Unmarshal(out, &again); err != nil {
+		t.Fatalf("Unmarshal() error: %v", err)
+	}
+	if !reflect.DeepEqual(again, s) {
+		t.Errorf("round trip = %+v, want %+v", again, s)
+	}
+
+	err = checkUnknownFields(s)
+	if !errors.Is(err, ErrUnknownFields) || !strings.Contains(err.Error(), "headers.x-kpsdk-new") {
+		t.Errorf("checkUnknownFields() = %v, want ErrUnknownFields naming headers.x-kpsdk-new", err)
+	}
+}
+
+func TestTaskResultRaw_DecodeSolution(t *testing.T) {
+	raw := &TaskResultRaw{Solution: json.RawMessage(`{"cookie":"c","extra":true}`)}
+
+	got, err := raw.DecodeSolution("DataDomeSliderSolver")
+	if err != nil {
+		t.Fatalf("DecodeSolution() error: %v", err)
+	}
+	s, ok := got.(DataDomeSliderSolution)
+	if !ok || s.Cookie != "c" || string(s.Extra["extra"]) != "true" {
+		t.Errorf("DecodeSolution() = %#v, want DataDomeSliderSolution with Extra", got)
+	}
+
+	if _, err := raw.DecodeSolutionStrict("DataDomeSliderSolver"); !errors.Is(err, ErrUnknownFields) {
+		t.Errorf("errors.Is(err, ErrUnknownFields) = false, want true; err = %v", err)
+	}
+	if _, err := raw.DecodeSolution("NoSuchSolver"); !errors.Is(err, ErrUnsupportedTaskOptionsType) {
+		t.Errorf("errors.Is(err, ErrUnsupportedTaskOptionsType) = false, want true; err = %v", err)
+	}
+}
+
+func TestTaskResultRaw_DecodeSolutionFor(t *testing.T) {
+	raw := &TaskResultRaw{Solution: json.RawMessage(`{"token":"tok","renewInSec":300,"user-agent":"UA"}`)}
+
+	// By name, the default Reese84Solution does not declare these fields.
+	if _, err := raw.DecodeSolutionStrict("IncapsulaReese84Solver"); !errors.Is(err, ErrUnknownFields) {
+		t.Errorf("DecodeSolutionStrict() error = %v, want ErrUnknownFields", err)
+	}
+
+	got, err := raw.DecodeSolutionForStrict(Reese84Options{Website: "https://example.com", SubmitPayload: true})
+	if err != nil {
+		t.Fatalf("DecodeSolutionForStrict() error: %v", err)
+	}
+	if s, ok := got.(Reese84SubmitPayloadSolution); !ok || s.Token != "tok" || s.RenewInSec != 300 {
+		t.Errorf("DecodeSolutionForStrict() = %#v, want Reese84SubmitPayloadSolution", got)
+	}
+
+	got, err = raw.DecodeSolutionFor(Reese84Options{Website: "https://example.com"})
+	if err != nil {
+		t.Fatalf("DecodeSolutionFor() error: %v", err)
+	}
+	if _, ok := got.(Reese84Solution); !ok {
+		t.Errorf("DecodeSolutionFor() = %#v, want Reese84Solution without SubmitPayload", got)
+	}
+
+	var methodErr *MethodError
+	if _, err := raw.DecodeSolutionFor("not options"); !errors.As(err, &methodErr) {
+		t.Errorf("DecodeSolutionFor() error = %v, want *MethodError", err)
+	}
+}
+
+func TestWithStrictDecoding(t *testing.T) {
+	h := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == EndpointCreateTask {
+			w.Write([]byte(`{"error_code":0,"taskId":"task-1"}`))
+			return
+		}
+		w.Write([]byte(`{"errorId":0,"status":"ready","solution":{"user-agent":"UA","x-new":"1"}}`))
+	}
+	options := KasadaStandardOptions{Pjs: "https://example.com/p.js"}
+
+	t.Run("lenient", func(t *testing.T) {
+		c, closeFn := newTestClient(t, h)
+		defer closeFn()
+
+		result, err := Solve[KasadaStandardSolution](c, context.Background(), options)
+		if err != nil {
+			t.Fatalf("Solve() error: %v", err)
+		}
+		if string(result.Solution.Extra["x-new"]) != `"1"` {
+			t.Errorf("Extra = %s, want x-new", result.Solution.Extra)
+		}
+	})
+
+	t.Run("strict", func(t *testing.T) {
+		c, closeFn := newTestClient(t, h, WithStrictDecoding())
+		defer closeFn()
+
+		if _, err := Solve[KasadaStandardSolution](c, context.Background(), options); !errors.Is(err, ErrUnknownFields) {
+			t.Errorf("Solve(): errors.Is(err, ErrUnknownFields) = false, want true; err = %v", err)
+		}
+		f := Submit[KasadaStandardSolution](c, context.Background(), options)
+		if _, err := f.Result(); !errors.Is(err, ErrUnknownFields) {
+			t.Errorf("Result(): errors.Is(err, ErrUnknownFields) = false, want true; err = %v", err)
+		}
+		// Raw results are not checked.
+		if _, err := c.Task(context.Background(), "task-1"); err != nil {
+			t.Errorf("Task() error: %v", err)
+		}
+	})
+}
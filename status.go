@@ -0,0 +1,74 @@
+package salamoonder
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// TaskStatus is the status of a task as reported by /getTaskResult.
+// Values the library does not know are kept as is, see Known.
+type TaskStatus string
+
+const (
+	TaskStatusUnknown    TaskStatus = "unknown"
+	TaskStatusPending    TaskStatus = "pending"
+	TaskStatusProcessing TaskStatus = "processing"
+	TaskStatusReady      TaskStatus = "ready"
+	TaskStatusFailed     TaskStatus = "failed"
+)
+
+// IsReady reports whether the solution is available.
+func (s TaskStatus) IsReady() bool {
+	return s == TaskStatusReady
+}
+
+// IsFailed reports whether the task failed.
+func (s TaskStatus) IsFailed() bool {
+	return s == TaskStatusFailed
+}
+
+// IsTerminal reports whether the task will not change its status anymore.
+func (s TaskStatus) IsTerminal() bool {
+	return s.IsReady() || s.IsFailed()
+}
+
+// Known reports whether s is one of the TaskStatus constants with a meaning:
+// TaskStatusUnknown, reported for a missing or null status, is not known.
+func (s TaskStatus) Known() bool {
+	switch s {
+	case TaskStatusPending, TaskStatusProcessing, TaskStatusReady, TaskStatusFailed:
+		return true
+	default:
+		return false
+	}
+}
+
+func (s TaskStatus) String() string {
+	return string(s)
+}
+
+// UnmarshalJSON normalizes the status reported by the API. It never fails on
+// unexpected string values so that new server statuses do not break decoding.
+func (s *TaskStatus) UnmarshalJSON(data []byte) error {
+	var raw *string
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if raw == nil {
+		*s = TaskStatusUnknown
+		return nil
+	}
+	*s = parseTaskStatus(*raw)
+	return nil
+}
+
+func parseTaskStatus(v string) TaskStatus {
+	switch v = strings.ToLower(strings.TrimSpace(v)); v {
+	case "":
+		return TaskStatusUnknown
+	case "error", "failure":
+		return TaskStatusFailed
+	default:
+		return TaskStatus(v)
+	}
+}
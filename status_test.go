@@ -0,0 +1,50 @@
+package salamoonder
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestTaskStatus_UnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		in       string
+		want     TaskStatus
+		known    bool
+		terminal bool
+	}{
+		{`"ready"`, TaskStatusReady, true, true},
+		{`"Ready"`, TaskStatusReady, true, true},
+		{`"pending"`, TaskStatusPending, true, false},
+		{`"processing"`, TaskStatusProcessing, true, false},
+		{`"failed"`, TaskStatusFailed, true, true},
+		{`"error"`, TaskStatusFailed, true, true},
+		{`""`, TaskStatusUnknown, false, false},
+		{`null`, TaskStatusUnknown, false, false},
+		{`"queued"`, TaskStatus("queued"), false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			var got TaskStatus
+			if err := json.Unmarshal([]byte(tt.in), &got); err != nil {
+				t.Fatalf("Unmarshal(%s) error: %v", tt.in, err)
+			}
+			if got != tt.want {
+				t.Errorf("Unmarshal(%s) = %q, want %q", tt.in, got, tt.want)
+			}
+			if got.Known() != tt.known {
+				t.Errorf("Known() = %v, want %v", got.Known(), tt.known)
+			}
+			if got.IsTerminal() != tt.terminal {
+				t.Errorf("IsTerminal() = %v, want %v", got.IsTerminal(), tt.terminal)
+			}
+		})
+	}
+}
+
+func TestTaskStatus_UnmarshalJSON_Invalid(t *testing.T) {
+	var got TaskStatus
+	if err := json.Unmarshal([]byte(`42`), &got); err == nil {
+		t.Fatal("Unmarshal(42) expected error, got nil")
+	}
+}
@@ -0,0 +1,261 @@
+package salamoonder
+
+import (
+	"context"
+	"crypto/tls"
+	"log/slog"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+type (
+	// HTTPTimings breaks down one HTTP exchange. Phases that did not happen,
+	// such as DNS and TLS on a reused connection, are zero.
+	HTTPTimings struct {
+		DNS     time.Duration
+		Connect time.Duration
+		TLS     time.Duration
+		// ServerProcessing is the time from the request being written to
+		// the first response byte.
+		ServerProcessing time.Duration
+		// TimeToFirstByte is the time from the start of the exchange to the
+		// first response byte.
+		TimeToFirstByte time.Duration
+		// BodyRead is the time spent reading the response body.
+		BodyRead time.Duration
+		// Total is the time of the whole exchange.
+		Total time.Duration
+		// ConnReused reports whether an idle connection was reused.
+		ConnReused bool
+	}
+
+	// TaskTimings breaks down the life of one task, from the call that
+	// created it to the poll that found it finished.
+	TaskTimings struct {
+		// Queue is the time from the start of Solve, Submit or a pool job
+		// to sending /createTask, spent on validation, budget checks and
+		// waiting for rate and concurrency limits.
+		Queue time.Duration
+		// Create is the time from sending /createTask to receiving the
+		// task id, including retries.
+		Create time.Duration
+		// UntilReady is the time from receiving the task id to the last
+		// poll.
+		UntilReady time.Duration
+		// Polls counts the /getTaskResult calls made for the task.
+		Polls int
+	}
+
+	taskClockKey struct{}
+
+	// taskClock collects the timestamps behind TaskTimings. It travels in
+	// the context of the calls made for one task.
+	taskClock struct {
+		mu       sync.Mutex
+		start    time.Time
+		sent     time.Time
+		created  time.Time
+		lastPoll time.Time
+		polls    int
+	}
+
+	// httpTimer records httptrace events for one exchange.
+	httpTimer struct {
+		mu           sync.Mutex
+		start        time.Time
+		dnsStart     time.Time
+		connectStart time.Time
+		tlsStart     time.Time
+		wrote        time.Time
+		firstByte    time.Time
+		timings      HTTPTimings
+	}
+
+	// httpTimed is implemented by results that carry the HTTPTimings of the
+	// call that produced them.
+	httpTimed interface {
+		setHTTPTimings(t *HTTPTimings)
+	}
+)
+
+// WithHTTPTrace captures net/http/httptrace timings for every API call
+// attempt. They are reported in Response.Timings to middleware, in the
+// HTTPTimings field of returned results and in log records.
+func WithHTTPTrace() Option {
+	return func(c *client) {
+		c.httpTrace = true
+	}
+}
+
+// LogValue groups the non-zero phases when the timings are logged with
+// log/slog.
+func (t *HTTPTimings) LogValue() slog.Value {
+	attrs := make([]slog.Attr, 0, 8)
+	for _, phase := range []struct {
+		key string
+		d   time.Duration
+	}{
+		{"dns", t.DNS},
+		{"connect", t.Connect},
+		{"tls", t.TLS},
+		{"server_processing", t.ServerProcessing},
+		{"ttfb", t.TimeToFirstByte},
+		{"body_read", t.BodyRead},
+		{"total", t.Total},
+	} {
+		if phase.d > 0 {
+			attrs = append(attrs, slog.Duration(phase.key, phase.d))
+		}
+	}
+	attrs = append(attrs, slog.Bool("conn_reused", t.ConnReused))
+	return slog.GroupValue(attrs...)
+}
+
+func (r *CreateTaskResult) setHTTPTimings(t *HTTPTimings)        { r.HTTPTimings = t }
+func (r *CreateTaskBalanceResult) setHTTPTimings(t *HTTPTimings) { r.HTTPTimings = t }
+func (r *TaskResult[TS]) setHTTPTimings(t *HTTPTimings)          { r.HTTPTimings = t }
+func (r *TaskResultRaw) setHTTPTimings(t *HTTPTimings)           { r.HTTPTimings = t }
+
+func newHTTPTimer() *httpTimer {
+	return &httpTimer{start: time.Now()}
+}
+
+func (t *httpTimer) trace() *httptrace.ClientTrace {
+	mark := func(f func(now time.Time)) {
+		now := time.Now()
+		t.mu.Lock()
+		f(now)
+		t.mu.Unlock()
+	}
+	return &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			mark(func(time.Time) { t.timings.ConnReused = info.Reused })
+		},
+		DNSStart: func(httptrace.DNSStartInfo) {
+			mark(func(now time.Time) { t.dnsStart = now })
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			mark(func(now time.Time) { t.timings.DNS = now.Sub(t.dnsStart) })
+		},
+		// Dialing may try several addresses in parallel; the connect phase
+		// spans from the first attempt to the last one to finish.
+		ConnectStart: func(string, string) {
+			mark(func(now time.Time) {
+				if t.connectStart.IsZero() {
+					t.connectStart = now
+				}
+			})
+		},
+		ConnectDone: func(string, string, error) {
+			mark(func(now time.Time) { t.timings.Connect = now.Sub(t.connectStart) })
+		},
+		TLSHandshakeStart: func() {
+			mark(func(now time.Time) { t.tlsStart = now })
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			mark(func(now time.Time) { t.timings.TLS = now.Sub(t.tlsStart) })
+		},
+		WroteRequest: func(httptrace.WroteRequestInfo) {
+			mark(func(now time.Time) { t.wrote = now })
+		},
+		GotFirstResponseByte: func() {
+			mark(func(now time.Time) { t.firstByte = now })
+		},
+	}
+}
+
+// finish completes the timings once the body was read, which started at
+// bodyStart.
+func (t *httpTimer) finish(bodyStart time.Time) *HTTPTimings {
+	now := time.Now()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	timings := t.timings
+	if !t.firstByte.IsZero() {
+		timings.TimeToFirstByte = t.firstByte.Sub(t.start)
+		if !t.wrote.IsZero() {
+			timings.ServerProcessing = t.firstByte.Sub(t.wrote)
+		}
+	}
+	timings.BodyRead = now.Sub(bodyStart)
+	timings.Total = now.Sub(t.start)
+	return &timings
+}
+
+// withTaskClock returns a context that collects TaskTimings for the task
+// created and polled with it, starting at start. A clock already present is
+// kept, so that a pool job's clock survives WaitForResult.
+func withTaskClock(ctx context.Context, start time.Time) (context.Context, *taskClock) {
+	if clock := taskClockFromContext(ctx); clock != nil {
+		return ctx, clock
+	}
+	clock := &taskClock{start: start}
+	return context.WithValue(ctx, taskClockKey{}, clock), clock
+}
+
+func taskClockFromContext(ctx context.Context) *taskClock {
+	clock, _ := ctx.Value(taskClockKey{}).(*taskClock)
+	return clock
+}
+
+// sending marks the first /createTask attempt being sent.
+func (k *taskClock) sending() {
+	if k == nil {
+		return
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if k.sent.IsZero() {
+		k.sent = time.Now()
+	}
+}
+
+// createdTask marks the task id being received.
+func (k *taskClock) createdTask() {
+	if k == nil {
+		return
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.created = time.Now()
+}
+
+// polled marks a /getTaskResult call finishing.
+func (k *taskClock) polled() {
+	if k == nil {
+		return
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.polls++
+	k.lastPoll = time.Now()
+}
+
+// timings returns the breakdown so far. For a task that was not created
+// through the clock's context, e.g. one only waited for by WaitForResult,
+// UntilReady counts from the clock's start.
+func (k *taskClock) timings() *TaskTimings {
+	if k == nil {
+		return nil
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	t := &TaskTimings{Polls: k.polls}
+	created := k.created
+	if created.IsZero() {
+		created = k.start
+	} else if !k.sent.IsZero() {
+		t.Queue = k.sent.Sub(k.start)
+		t.Create = k.created.Sub(k.sent)
+	}
+	if !k.lastPoll.IsZero() {
+		t.UntilReady = k.lastPoll.Sub(created)
+	}
+	return t
+}
@@ -0,0 +1,141 @@
+package salamoonder
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestWithHTTPTrace(t *testing.T) {
+	var seen []*HTTPTimings
+	mw := func(next Handler) Handler {
+		return func(ctx context.Context, call *Call) (*Response, error) {
+			resp, err := next(ctx, call)
+			if resp != nil {
+				seen = append(seen, resp.Timings)
+			}
+			return resp, err
+		}
+	}
+	c, closeFn := newTestClient(t, (&fakeAPI{}).ServeHTTP, WithHTTPTrace(), WithMiddleware(mw))
+	defer closeFn()
+
+	first, err := c.Balance(context.Background())
+	if err != nil {
+		t.Fatalf("Balance() error: %v", err)
+	}
+	second, err := c.Balance(context.Background())
+	if err != nil {
+		t.Fatalf("Balance() error: %v", err)
+	}
+
+	if first.HTTPTimings == nil || second.HTTPTimings == nil {
+		t.Fatalf("HTTPTimings = %v, %v; want both set", first.HTTPTimings, second.HTTPTimings)
+	}
+	if first.HTTPTimings.ConnReused || first.HTTPTimings.Connect <= 0 {
+		t.Errorf("first call timings = %+v, want a new connection", *first.HTTPTimings)
+	}
+	if !second.HTTPTimings.ConnReused || second.HTTPTimings.Connect != 0 {
+		t.Errorf("second call timings = %+v, want a reused connection", *second.HTTPTimings)
+	}
+	if tm := first.HTTPTimings; tm.TimeToFirstByte <= 0 || tm.Total < tm.TimeToFirstByte {
+		t.Errorf("first call timings = %+v, want 0 < TimeToFirstByte <= Total", *tm)
+	}
+	if len(seen) != 2 || seen[0] != first.HTTPTimings {
+		t.Errorf("middleware saw %v, want the timings returned with the results", seen)
+	}
+}
+
+func TestWithHTTPTrace_Disabled(t *testing.T) {
+	c, closeFn := newTestClient(t, (&fakeAPI{}).ServeHTTP)
+	defer closeFn()
+
+	result, err := c.Balance(context.Background())
+	if err != nil {
+		t.Fatalf("Balance() error: %v", err)
+	}
+	if result.HTTPTimings != nil {
+		t.Errorf("HTTPTimings = %+v, want nil", *result.HTTPTimings)
+	}
+}
+
+func TestSolve_TaskTimings(t *testing.T) {
+	const createDelay = 20 * time.Millisecond
+	api := &fakeAPI{Pending: 2}
+	h := func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == EndpointCreateTask {
+			time.Sleep(createDelay)
+		}
+		api.ServeHTTP(w, r)
+	}
+	c, closeFn := newTestClient(t, h)
+	defer closeFn()
+
+	options := KasadaStandardOptions{Pjs: "https://example.com/p.js"}
+	result, err := Solve[KasadaStandardSolution](c, context.Background(), options, WithPollInterval(10*time.Millisecond))
+	if err != nil {
+		t.Fatalf("Solve() error: %v", err)
+	}
+
+	tm := result.TaskTimings
+	if tm == nil {
+		t.Fatal("TaskTimings = nil, want set")
+	}
+	if tm.Polls != 3 {
+		t.Errorf("Polls = %d, want 3", tm.Polls)
+	}
+	if tm.Create < createDelay {
+		t.Errorf("Create = %v, want at least %v", tm.Create, createDelay)
+	}
+	if tm.UntilReady < 20*time.Millisecond {
+		t.Errorf("UntilReady = %v, want at least two poll intervals", tm.UntilReady)
+	}
+}
+
+func TestFuture_Timings(t *testing.T) {
+	c, closeFn := newTestClient(t, (&fakeAPI{}).ServeHTTP)
+	defer closeFn()
+
+	f := Submit[KasadaStandardSolution](c, context.Background(), KasadaStandardOptions{Pjs: "https://example.com/p.js"})
+	if _, err := f.Result(); err != nil {
+		t.Fatalf("Result() error: %v", err)
+	}
+	if tm := f.Timings(); tm == nil || tm.Polls != 1 || tm.Create <= 0 {
+		t.Errorf("Timings() = %+v, want one poll and a creation latency", tm)
+	}
+}
+
+func TestPool_TaskTimingsIncludeQueue(t *testing.T) {
+	const pollDelay = 30 * time.Millisecond
+	api := &fakeAPI{}
+	h := func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == EndpointGetTaskResult {
+			time.Sleep(pollDelay)
+		}
+		api.ServeHTTP(w, r)
+	}
+	c, closeFn := newTestClient(t, h)
+	defer closeFn()
+
+	p := NewPool(c, WithWorkers(1))
+	results := make(chan JobResult, 2)
+	for range 2 {
+		job := Job{Options: KasadaStandardOptions{Pjs: "https://example.com/p.js"}, Result: results}
+		if err := p.Submit(context.Background(), job); err != nil {
+			t.Fatalf("Submit() error: %v", err)
+		}
+	}
+	if err := p.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error: %v", err)
+	}
+
+	<-results
+	second := <-results
+	if second.Err != nil {
+		t.Fatalf("JobResult.Err = %v", second.Err)
+	}
+	if tm := second.Result.TaskTimings; tm == nil || tm.Queue < pollDelay || tm.UntilReady < pollDelay {
+		t.Errorf("TaskTimings = %+v, want Queue and UntilReady of at least %v", tm, pollDelay)
+	}
+}
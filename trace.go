@@ -0,0 +1,75 @@
+package salamoonder
+
+import (
+	"context"
+	"log/slog"
+)
+
+// Span names used by the client.
+const (
+	SpanBalance       = "salamoonder.Balance"
+	SpanCreateTask    = "salamoonder.CreateTask"
+	SpanTask          = "salamoonder.Task"
+	SpanGetTaskResult = "salamoonder.GetTaskResult"
+	SpanPoll          = "salamoonder.poll"
+	SpanAttempt       = "salamoonder.attempt"
+)
+
+// Attribute keys set on spans.
+const (
+	AttrTaskType   = "salamoonder.task_type"
+	AttrTaskId     = "salamoonder.task_id"
+	AttrStatus     = "salamoonder.status"
+	AttrAttempt    = "salamoonder.attempt"
+	AttrEndpoint   = "salamoonder.endpoint"
+	AttrHTTPStatus = "http.response.status_code"
+)
+
+type (
+	/*
+		Tracer starts spans around client operations. It is small enough to be
+		bridged to OpenTelemetry or any other tracing library without this
+		module depending on it: Start maps to tracer.Start, attributes are
+		slog.Attr values, and Span.End records the error, if any, and ends the
+		span.
+
+		Spans nest through the returned context, which is also the context of
+		the HTTP request, so transports that propagate trace context see the
+		attempt span as the active one.
+	*/
+	Tracer interface {
+		Start(ctx context.Context, name string, attrs ...slog.Attr) (context.Context, Span)
+	}
+
+	// Span is an operation started by a Tracer.
+	Span interface {
+		SetAttributes(attrs ...slog.Attr)
+		// End finishes the span; err is the operation's error or nil.
+		End(err error)
+	}
+
+	noopSpan struct{}
+)
+
+// WithTracer traces Balance, CreateTask, Task, GetTaskResult, every poll
+// iteration of WaitForResult, Solve, Submit and Resume, and every HTTP
+// attempt made by them.
+func WithTracer(t Tracer) Option {
+	return func(c *client) {
+		c.tracer = t
+	}
+}
+
+func (c *client) startSpan(ctx context.Context, name string, attrs ...slog.Attr) (context.Context, Span) {
+	if c.tracer == nil {
+		return ctx, noopSpan{}
+	}
+	if taskType := taskTypeFromContext(ctx); taskType != "" {
+		attrs = append(attrs, slog.String(AttrTaskType, taskType))
+	}
+	return c.tracer.Start(ctx, name, attrs...)
+}
+
+func (noopSpan) SetAttributes(...slog.Attr) {}
+
+func (noopSpan) End(error) {}
@@ -0,0 +1,171 @@
+package salamoonder
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+type (
+	recordingTracer struct {
+		mu    sync.Mutex
+		spans []*recordedSpan
+	}
+
+	recordedSpan struct {
+		name   string
+		parent *recordedSpan
+		attrs  map[string]any
+		err    error
+		ended  bool
+	}
+
+	spanKey struct{}
+)
+
+func (t *recordingTracer) Start(ctx context.Context, name string, attrs ...slog.Attr) (context.Context, Span) {
+	parent, _ := ctx.Value(spanKey{}).(*recordedSpan)
+	s := &recordedSpan{name: name, parent: parent, attrs: map[string]any{}}
+	s.SetAttributes(attrs...)
+
+	t.mu.Lock()
+	t.spans = append(t.spans, s)
+	t.mu.Unlock()
+	return context.WithValue(ctx, spanKey{}, s), s
+}
+
+func (s *recordedSpan) SetAttributes(attrs ...slog.Attr) {
+	for _, a := range attrs {
+		s.attrs[a.Key] = a.Value.Any()
+	}
+}
+
+func (s *recordedSpan) End(err error) {
+	s.err = err
+	s.ended = true
+}
+
+func (t *recordingTracer) named(name string) []*recordedSpan {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var out []*recordedSpan
+	for _, s := range t.spans {
+		if s.name == name {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+func TestTracer_Solve(t *testing.T) {
+	polls := 0
+	h := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == EndpointCreateTask {
+			w.Write([]byte(`{"error_code":0,"taskId":"task-1"}`))
+			return
+		}
+		if polls++; polls == 1 {
+			w.Write([]byte(`{"errorId":0,"status":"pending","solution":null}`))
+			return
+		}
+		w.Write([]byte(`{"errorId":0,"status":"ready","solution":{}}`))
+	}
+	tracer := &recordingTracer{}
+	c, closeFn := newTestClient(t, h, WithTracer(tracer))
+	defer closeFn()
+
+	options := KasadaStandardOptions{Pjs: "https://example.com/p.js"}
+	if _, err := Solve[KasadaStandardSolution](c, context.Background(), options, WithPollInterval(time.Millisecond)); err != nil {
+		t.Fatalf("Solve() error: %v", err)
+	}
+
+	for _, s := range tracer.spans {
+		if !s.ended {
+			t.Errorf("span %s not ended", s.name)
+		}
+		if s.attrs[AttrTaskType] != "KasadaCaptchaSolver" {
+			t.Errorf("span %s task type = %v, want KasadaCaptchaSolver", s.name, s.attrs[AttrTaskType])
+		}
+	}
+
+	create := tracer.named(SpanCreateTask)
+	if len(create) != 1 || create[0].attrs[AttrTaskId] != "task-1" {
+		t.Fatalf("CreateTask spans = %+v, want one for task-1", create)
+	}
+
+	polled := tracer.named(SpanPoll)
+	if len(polled) != 2 {
+		t.Fatalf("poll spans = %d, want 2", len(polled))
+	}
+	if polled[0].attrs[AttrAttempt] != int64(1) || polled[1].attrs[AttrAttempt] != int64(2) {
+		t.Errorf("poll attempts = %v, %v; want 1, 2", polled[0].attrs[AttrAttempt], polled[1].attrs[AttrAttempt])
+	}
+	if polled[1].attrs[AttrStatus] != "ready" || polled[0].attrs[AttrStatus] != "pending" {
+		t.Errorf("poll statuses = %v, %v; want pending, ready", polled[0].attrs[AttrStatus], polled[1].attrs[AttrStatus])
+	}
+
+	results := tracer.named(SpanGetTaskResult)
+	if len(results) != 2 || results[0].parent != polled[0] {
+		t.Errorf("GetTaskResult spans = %d, want 2 nested in poll spans", len(results))
+	}
+
+	attempts := tracer.named(SpanAttempt)
+	if len(attempts) != 3 || attempts[0].parent != create[0] || attempts[0].attrs[AttrHTTPStatus] != int64(200) {
+		t.Errorf("attempt spans = %d, want 3 with the first nested in CreateTask", len(attempts))
+	}
+}
+
+func TestTracer_Errors(t *testing.T) {
+	h := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"error_code":1,"error_description":"invalid api key"}`))
+	}
+	tracer := &recordingTracer{}
+	c, closeFn := newTestClient(t, h, WithTracer(tracer))
+	defer closeFn()
+
+	c.Balance(context.Background())
+	c.Task(context.Background(), "task-1")
+
+	balance := tracer.named(SpanBalance)
+	if len(balance) != 1 || !errors.Is(balance[0].err, ErrInvalidAPIKey) {
+		t.Errorf("Balance spans = %+v, want one ended with ErrInvalidAPIKey", balance)
+	}
+	task := tracer.named(SpanTask)
+	if len(task) != 1 || task[0].err == nil || task[0].attrs[AttrTaskId] != "task-1" {
+		t.Errorf("Task spans = %+v, want one for task-1 ended with an error", task)
+	}
+	attempt := tracer.named(SpanAttempt)
+	if len(attempt) != 2 || attempt[0].attrs[AttrHTTPStatus] != int64(401) {
+		t.Errorf("attempt spans = %+v, want http status 401", attempt)
+	}
+}
+
+func TestTracer_SubmitPolls(t *testing.T) {
+	h := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == EndpointCreateTask {
+			w.Write([]byte(`{"error_code":0,"taskId":"task-1"}`))
+			return
+		}
+		w.Write([]byte(`{"errorId":0,"status":"ready","solution":{}}`))
+	}
+	tracer := &recordingTracer{}
+	c, closeFn := newTestClient(t, h, WithTracer(tracer))
+	defer closeFn()
+
+	f := Submit[KasadaStandardSolution](c, context.Background(), KasadaStandardOptions{Pjs: "https://example.com/p.js"})
+	if _, err := f.Result(); err != nil {
+		t.Fatalf("Result() error: %v", err)
+	}
+	polled := tracer.named(SpanPoll)
+	if len(polled) != 1 || polled[0].attrs[AttrStatus] != "ready" || polled[0].attrs[AttrTaskType] != "KasadaCaptchaSolver" {
+		t.Errorf("poll spans = %+v, want one ready poll", polled)
+	}
+}
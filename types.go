@@ -2,38 +2,16 @@ package salamoonder
 
 import (
 	"encoding/json"
-	"reflect"
 )
 
-func init() {
-	registerAllowedTypes(
-		KasadaStandardOptions{},
-		KasadaPayloadOptions{},
-		AkamaiWebOptions{},
-		AkamaiSBSDOptions{},
-		Reese84Options{},
-		UutmvcOptions{},
-		DataDomeInterstitialOptions{},
-		DataDomeSliderOptions{},
-		TwitchScraperOptions{},
-		TwitchIntegrityOptions{},
-	)
-}
-
-func registerAllowedTypes(values ...any) {
-	for _, v := range values {
-		allowedTaskTypes = append(allowedTaskTypes, reflect.TypeOf(v))
-	}
-}
-
 type (
-	TaskOptions interface {
-		KasadaStandardOptions | KasadaPayloadOptions | AkamaiWebOptions | AkamaiSBSDOptions | Reese84Options | UutmvcOptions | DataDomeInterstitialOptions | DataDomeSliderOptions | TwitchScraperOptions | TwitchIntegrityOptions
-	}
+	// TaskOptions is satisfied by any type, but CreateTask and Solve only
+	// accept options types registered with RegisterTaskType.
+	TaskOptions interface{}
 
-	TaskSolution interface {
-		KasadaStandardSolution | KasadaPayloadSolution | AkamaiWebSolution | AkamaiSBSDSolution | Reese84Solution | Reese84SubmitPayloadSolution | UutmvcSolution | DataDomeInterstitialSolution | DataDomeSliderSolution | TwitchScraperSolution | TwitchIntegritySolution
-	}
+	// TaskSolution is satisfied by any type the solution JSON decodes into,
+	// usually the solution type registered with RegisterTaskType.
+	TaskSolution interface{}
 
 	CreateTaskRequest struct {
 		ApiKey string      `json:"api_key"`
@@ -44,6 +22,9 @@ type (
 		ErrorCode        int    `json:"error_code"`
 		ErrorDescription string `json:"error_description"`
 		TaskId           string `json:"taskId"`
+
+		// HTTPTimings is set with WithHTTPTrace.
+		HTTPTimings *HTTPTimings `json:"-"`
 	}
 
 	// https://apidocs.salamoonder.com/endpoint/getBalance
@@ -51,6 +32,9 @@ type (
 		ErrorCode        int    `json:"error_code"`
 		ErrorDescription string `json:"error_description"`
 		Wallet           string `json:"wallet"`
+
+		// HTTPTimings is set with WithHTTPTrace.
+		HTTPTimings *HTTPTimings `json:"-"`
 	}
 
 	TaskRequest struct {
@@ -59,15 +43,33 @@ type (
 	}
 
 	TaskResult[TS TaskSolution] struct {
-		ErrorId  int    `json:"errorId"`
-		Solution TS     `json:"solution"`
-		Status   string `json:"status"`
+		ErrorId  int        `json:"errorId"`
+		Solution TS         `json:"solution"`
+		Status   TaskStatus `json:"status"`
+
+		// HTTPTimings is set with WithHTTPTrace and describes the call that
+		// returned the result.
+		HTTPTimings *HTTPTimings `json:"-"`
+		// TaskTimings is set by Solve.
+		TaskTimings *TaskTimings `json:"-"`
+		// FromCache reports that Solve returned a cached solution; see
+		// WithCache.
+		FromCache bool `json:"-"`
+		// Coalesced reports that Solve shared the task of a concurrent
+		// call; see WithCoalescing.
+		Coalesced bool `json:"-"`
 	}
 
 	TaskResultRaw struct {
 		ErrorId  int             `json:"errorId"`
 		Solution json.RawMessage `json:"solution"`
-		Status   string          `json:"status"`
+		Status   TaskStatus      `json:"status"`
+
+		// HTTPTimings is set with WithHTTPTrace and describes the call that
+		// returned the result.
+		HTTPTimings *HTTPTimings `json:"-"`
+		// TaskTimings is set by WaitForResult.
+		TaskTimings *TaskTimings `json:"-"`
 	}
 
 	// https://apidocs.salamoonder.com/tasks/kasada/standard
@@ -85,6 +87,8 @@ type (
 		XKpsdkCt  string `json:"x-kpsdk-ct"`
 		XKpsdkR   string `json:"x-kpsdk-r"`
 		XKpsdkSt  string `json:"x-kpsdk-st"`
+
+		Extra map[string]json.RawMessage `json:"-"`
 	}
 
 	// https://apidocs.salamoonder.com/tasks/kasada/payload
@@ -96,14 +100,21 @@ type (
 
 	// https://apidocs.salamoonder.com/tasks/kasada/payload
 	KasadaPayloadSolution struct {
-		Headers struct {
-			XKpsdkCt string `json:"x-kpsdk-ct"`
-			XKpsdkDt string `json:"x-kpsdk-dt"`
-			XKpsdkIm string `json:"x-kpsdk-im"`
-			XKpsdkV  string `json:"x-kpsdk-v"`
-		} `json:"headers"`
-		Payload   string `json:"payload"`
-		UserAgent string `json:"user-agent"`
+		Headers   KasadaPayloadHeaders `json:"headers"`
+		Payload   string               `json:"payload"`
+		UserAgent string               `json:"user-agent"`
+
+		Extra map[string]json.RawMessage `json:"-"`
+	}
+
+	// KasadaPayloadHeaders are the headers of a KasadaPayloadSolution.
+	KasadaPayloadHeaders struct {
+		XKpsdkCt string `json:"x-kpsdk-ct"`
+		XKpsdkDt string `json:"x-kpsdk-dt"`
+		XKpsdkIm string `json:"x-kpsdk-im"`
+		XKpsdkV  string `json:"x-kpsdk-v"`
+
+		Extra map[string]json.RawMessage `json:"-"`
 	}
 
 	// https://apidocs.salamoonder.com/tasks/akamai/web
@@ -124,6 +135,8 @@ type (
 		Payload   map[string]any `json:"payload"`
 		Data      map[string]any `json:"data"`
 		UserAgent string         `json:"user-agent"`
+
+		Extra map[string]json.RawMessage `json:"-"`
 	}
 
 	// https://apidocs.salamoonder.com/tasks/akamai/sbsd
@@ -139,6 +152,8 @@ type (
 	AkamaiSBSDSolution struct {
 		Payload   string `json:"payload"`
 		UserAgent string `json:"user-agent"`
+
+		Extra map[string]json.RawMessage `json:"-"`
 	}
 
 	// https://apidocs.salamoonder.com/api-documentation/tasks/incapsula/reese84
@@ -152,6 +167,8 @@ type (
 		Token      string `json:"token"`
 		RenewInSec int    `json:"renewInSec"`
 		UserAgent  string `json:"user-agent"`
+
+		Extra map[string]json.RawMessage `json:"-"`
 	}
 
 	// https://apidocs.salamoonder.com/tasks/incapsula/reese84
@@ -159,6 +176,8 @@ type (
 		Payload        string `json:"payload"`
 		UserAgent      string `json:"user-agent"`
 		AcceptLanguage string `json:"accept-language"`
+
+		Extra map[string]json.RawMessage `json:"-"`
 	}
 
 	// https://apidocs.salamoonder.com/tasks/incapsula/utmvc
@@ -170,6 +189,8 @@ type (
 	UutmvcSolution struct {
 		UserAgent string `json:"user-agent"`
 		Utmvc     string `json:"utmvc"`
+
+		Extra map[string]json.RawMessage `json:"-"`
 	}
 
 	// https://apidocs.salamoonder.com/tasks/twitch/scraper
@@ -180,6 +201,8 @@ type (
 		Biography      string `json:"biography"`
 		ProfilePicture string `json:"profile_picture"`
 		Username       string `json:"username"`
+
+		Extra map[string]json.RawMessage `json:"-"`
 	}
 
 	// https://apidocs.salamoonder.com/tasks/twitch/integrity
@@ -207,6 +230,8 @@ type (
 		IntegrityToken string `json:"integrity_token"`
 		UserAgent      string `json:"user-agent"`
 		ClientID       string `json:"client-id"`
+
+		Extra map[string]json.RawMessage `json:"-"`
 	}
 
 	// https://apidocs.salamoonder.com/tasks/twitch/local-integrity
@@ -235,6 +260,8 @@ type (
 		Proxy          string `json:"proxy"`
 		UserAgent      string `json:"user-agent"`
 		ClientID       string `json:"client-id"`
+
+		Extra map[string]json.RawMessage `json:"-"`
 	}
 
 	// https://apidocs.salamoonder.com/tasks/twitch/integrity
@@ -251,6 +278,8 @@ type (
 		ExpirationAt   int64  `json:"expiration"`
 		UserAgent      string `json:"user-agent"`
 		ClientID       string `json:"client-id"`
+
+		Extra map[string]json.RawMessage `json:"-"`
 	}
 
 	// https://apidocs.salamoonder.com/tasks/datadome/interstitial
@@ -264,6 +293,8 @@ type (
 	DataDomeInterstitialSolution struct {
 		Cookie    string `json:"cookie"`
 		UserAgent string `json:"user-agent"`
+
+		Extra map[string]json.RawMessage `json:"-"`
 	}
 
 	// https://apidocs.salamoonder.com/tasks/datadome/slider
@@ -277,5 +308,7 @@ type (
 	DataDomeSliderSolution struct {
 		Cookie    string `json:"cookie"`
 		UserAgent string `json:"user-agent"`
+
+		Extra map[string]json.RawMessage `json:"-"`
 	}
 )
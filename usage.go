@@ -0,0 +1,236 @@
+package salamoonder
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxTrackedTasks bounds the tasks whose type usage remembers. Finished
+// tasks are forgotten first.
+const maxTrackedTasks = 100_000
+
+// unknownTaskType labels usage of calls whose task type is not known, such
+// as Task for a task created elsewhere.
+const unknownTaskType = "unknown"
+
+type (
+	// TaskTypeUsage is the usage of one task type.
+	TaskTypeUsage struct {
+		TaskType string `json:"task_type"`
+		Created  int64  `json:"created"`
+		// Solved and Failed count tasks created or resumed by the client
+		// that reached that final status.
+		Solved int64 `json:"solved"`
+		Failed int64 `json:"failed"`
+		// Polls counts /getTaskResult calls.
+		Polls int64 `json:"polls"`
+		// Latency is the total duration of API calls, including retries.
+		Latency time.Duration `json:"latency_ns"`
+		// Cost is Created times the task type's price, see WithPrices.
+		Cost Balance `json:"cost"`
+	}
+
+	// UsageReport is a snapshot of the client's usage per task type.
+	UsageReport struct {
+		Since time.Time `json:"since"`
+		// Types is sorted by task type.
+		Types []TaskTypeUsage `json:"types"`
+	}
+
+	usage struct {
+		since time.Time
+
+		mu     sync.Mutex
+		prices map[string]Balance
+		types  map[string]*TaskTypeUsage
+		tasks  map[string]*trackedTask
+	}
+
+	trackedTask struct {
+		taskType string
+		done     bool
+	}
+)
+
+// WithPrices sets the price of one task per task type name, used for the
+// Cost of Usage. Without it, Budget.Prices are used if a budget is set.
+func WithPrices(prices map[string]Balance) Option {
+	return func(c *client) {
+		c.usage.prices = prices
+	}
+}
+
+func newUsage() *usage {
+	return &usage{
+		since: time.Now(),
+		types: make(map[string]*TaskTypeUsage),
+		tasks: make(map[string]*trackedTask),
+	}
+}
+
+// Usage returns a snapshot of the usage counters, which the client keeps
+// for every task it creates and polls.
+func (c *Client) Usage() UsageReport {
+	return c.usage.report()
+}
+
+// UsageHandler serves Usage in the Prometheus text exposition format, or as
+// JSON when the request accepts application/json or has ?format=json.
+func (c *Client) UsageHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := c.Usage()
+		if r.URL.Query().Get("format") == "json" || strings.Contains(r.Header.Get("Accept"), "application/json") {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(report)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		report.WritePrometheus(w)
+	})
+}
+
+// WritePrometheus writes r in the Prometheus text exposition format.
+func (r UsageReport) WritePrometheus(w io.Writer) error {
+	metrics := []struct {
+		name, help string
+		value      func(TaskTypeUsage) string
+	}{
+		{"salamoonder_tasks_created_total", "Tasks created.", func(u TaskTypeUsage) string { return fmt.Sprint(u.Created) }},
+		{"salamoonder_tasks_solved_total", "Tasks that became ready.", func(u TaskTypeUsage) string { return fmt.Sprint(u.Solved) }},
+		{"salamoonder_tasks_failed_total", "Tasks that failed.", func(u TaskTypeUsage) string { return fmt.Sprint(u.Failed) }},
+		{"salamoonder_polls_total", "Task result polls.", func(u TaskTypeUsage) string { return fmt.Sprint(u.Polls) }},
+		{"salamoonder_api_latency_seconds_total", "Total duration of API calls.", func(u TaskTypeUsage) string { return fmt.Sprint(u.Latency.Seconds()) }},
+		{"salamoonder_estimated_cost_total", "Estimated cost of created tasks.", func(u TaskTypeUsage) string { return u.Cost.String() }},
+	}
+
+	var b strings.Builder
+	for _, m := range metrics {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s counter\n", m.name, m.help, m.name)
+		for _, u := range r.Types {
+			fmt.Fprintf(&b, "%s{task_type=\"%s\"} %s\n", m.name, escapeLabel(u.TaskType), m.value(u))
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func escapeLabel(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+// created counts a task created for taskType at price.
+func (u *usage) created(taskType, taskId string, price Balance) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	t := u.get(taskType)
+	t.Created++
+	t.Cost = t.Cost.Add(price)
+	u.track(taskId, taskType)
+}
+
+// track remembers an outstanding task. u.mu must be held.
+func (u *usage) track(taskId, taskType string) {
+	if len(u.tasks) >= maxTrackedTasks {
+		for id, t := range u.tasks {
+			if t.done {
+				delete(u.tasks, id)
+			}
+		}
+	}
+	if len(u.tasks) < maxTrackedTasks {
+		u.tasks[taskId] = &trackedTask{taskType: taskType}
+	}
+}
+
+// resumed remembers a task picked up from a journal.
+func (u *usage) resumed(taskId, taskType string) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.track(taskId, taskType)
+}
+
+// polled counts a /getTaskResult call and, for outstanding tasks, its final
+// status.
+func (u *usage) polled(taskType, taskId string, status TaskStatus) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	tracked := u.tasks[taskId]
+	if taskType == "" && tracked != nil {
+		taskType = tracked.taskType
+	}
+	t := u.get(taskType)
+	t.Polls++
+	if tracked == nil || tracked.done || !status.IsTerminal() {
+		return
+	}
+	tracked.done = true
+	if status.IsReady() {
+		t.Solved++
+	} else {
+		t.Failed++
+	}
+}
+
+// latency adds the duration of an API call made for a task.
+func (u *usage) latency(call *Call, d time.Duration) {
+	if call.Endpoint == EndpointGetBalance {
+		return
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	taskType := call.TaskType
+	if req, ok := call.Request.(TaskRequest); ok && taskType == "" && u.tasks[req.TaskId] != nil {
+		taskType = u.tasks[req.TaskId].taskType
+	}
+	t := u.get(taskType)
+	t.Latency += d
+}
+
+// get returns the counters of taskType. u.mu must be held.
+func (u *usage) get(taskType string) *TaskTypeUsage {
+	if taskType == "" {
+		taskType = unknownTaskType
+	}
+	t, ok := u.types[taskType]
+	if !ok {
+		t = &TaskTypeUsage{TaskType: taskType}
+		u.types[taskType] = t
+	}
+	return t
+}
+
+func (u *usage) report() UsageReport {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	r := UsageReport{Since: u.since, Types: make([]TaskTypeUsage, 0, len(u.types))}
+	for _, t := range u.types {
+		r.Types = append(r.Types, *t)
+	}
+	sort.Slice(r.Types, func(a, b int) bool {
+		return r.Types[a].TaskType < r.Types[b].TaskType
+	})
+	return r
+}
+
+// price returns the price of one taskType task for usage accounting.
+func (c *Client) price(taskType string) Balance {
+	if p, ok := c.usage.prices[taskType]; ok {
+		return p
+	}
+	if c.budget != nil {
+		return c.budget.price(taskType)
+	}
+	return Balance{}
+}
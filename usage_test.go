@@ -0,0 +1,115 @@
+package salamoonder
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newUsageClient(t *testing.T, opts ...Option) (*Client, func()) {
+	t.Helper()
+	return newTestClient(t, (&fakeAPI{Pending: 1}).ServeHTTP, opts...)
+}
+
+func TestUsage_Counters(t *testing.T) {
+	c, closeFn := newUsageClient(t, WithPrices(map[string]Balance{"KasadaCaptchaSolver": MustParseBalance("0.0015")}))
+	defer closeFn()
+
+	options := KasadaStandardOptions{Pjs: "https://example.com/p.js"}
+	if _, err := Solve[KasadaStandardSolution](c, context.Background(), options, WithPollInterval(time.Millisecond)); err != nil {
+		t.Fatalf("Solve() error: %v", err)
+	}
+	// Polling a finished task again counts the poll but not another solve.
+	c.Task(context.Background(), "task-1")
+	c.Task(context.Background(), "task-1")
+	c.Balance(context.Background())
+
+	report := c.Usage()
+	if len(report.Types) != 1 {
+		t.Fatalf("Types = %+v, want only KasadaCaptchaSolver", report.Types)
+	}
+	u := report.Types[0]
+	if u.TaskType != "KasadaCaptchaSolver" || u.Created != 1 || u.Solved != 1 || u.Failed != 0 || u.Polls != 4 {
+		t.Errorf("usage = %+v, want 1 created, 1 solved, 4 polls", u)
+	}
+	if u.Cost.String() != "0.0015" {
+		t.Errorf("Cost = %s, want 0.0015", u.Cost)
+	}
+	if u.Latency <= 0 {
+		t.Errorf("Latency = %v, want > 0", u.Latency)
+	}
+}
+
+func TestUsage_FailedAndBudgetPrices(t *testing.T) {
+	c, closeFn := newUsageClient(t, WithBudget(Budget{DefaultPrice: MustParseBalance("2")}))
+	defer closeFn()
+
+	journal := NewMemoryJournal()
+	journal.Created(JournalEntry{TaskId: "failed", TaskType: "AkamaiWebSolver"})
+	c.journal = journal
+	if _, err := c.Resume(context.Background(), WithPollInterval(time.Millisecond)); err != nil {
+		t.Fatalf("Resume() error: %v", err)
+	}
+	c.CreateTask(context.Background(), KasadaStandardOptions{Pjs: "https://example.com/p.js"})
+
+	report := c.Usage()
+	byType := map[string]TaskTypeUsage{}
+	for _, u := range report.Types {
+		byType[u.TaskType] = u
+	}
+	if u := byType["AkamaiWebSolver"]; u.Failed != 1 || u.Polls != 1 {
+		t.Errorf("AkamaiWebSolver usage = %+v, want 1 failed poll", u)
+	}
+	if u := byType["KasadaCaptchaSolver"]; u.Created != 1 || u.Cost.String() != "2.00" {
+		t.Errorf("KasadaCaptchaSolver usage = %+v, want cost from budget", u)
+	}
+}
+
+func TestUsageHandler(t *testing.T) {
+	c, closeFn := newUsageClient(t)
+	defer closeFn()
+	c.CreateTask(context.Background(), KasadaStandardOptions{Pjs: "https://example.com/p.js"})
+
+	srv := httptest.NewServer(c.UsageHandler())
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	for _, want := range []string{
+		"# TYPE salamoonder_tasks_created_total counter",
+		`salamoonder_tasks_created_total{task_type="KasadaCaptchaSolver"} 1`,
+		`salamoonder_estimated_cost_total{task_type="KasadaCaptchaSolver"} 0.00`,
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("metrics missing %q:\n%s", want, body)
+		}
+	}
+
+	resp, err = http.Get(srv.URL + "?format=json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var report UsageReport
+	if err := json.NewDecoder(resp.Body).Decode(&report); err != nil {
+		t.Fatalf("decode JSON report: %v", err)
+	}
+	if len(report.Types) != 1 || report.Types[0].Created != 1 {
+		t.Errorf("JSON report = %+v, want 1 created", report)
+	}
+}
+
+func TestEscapeLabel(t *testing.T) {
+	if got := escapeLabel("a\"b\\c\nd"); got != `a\"b\\c\nd` {
+		t.Errorf("escapeLabel() = %s", got)
+	}
+}
@@ -0,0 +1,188 @@
+package salamoonder
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+type (
+	// Validator is implemented by options types that can check themselves
+	// before a task is created. Custom task types may implement it too.
+	Validator interface {
+		Validate() error
+	}
+
+	// FieldError describes a single invalid options field. Field is the
+	// JSON name of the field.
+	FieldError struct {
+		Field string
+		Msg   string
+	}
+
+	// ValidationError lists every invalid field of an options value.
+	ValidationError struct {
+		// Options is the name of the options type.
+		Options string
+		Fields  []FieldError
+	}
+
+	fieldErrors []FieldError
+)
+
+// WithoutValidation disables the Validate call CreateTask and Solve make
+// before creating a task.
+func WithoutValidation() Option {
+	return func(c *client) {
+		c.skipValidation = true
+	}
+}
+
+func (f FieldError) String() string {
+	return fmt.Sprintf("%s: %s", f.Field, f.Msg)
+}
+
+func (v *ValidationError) Error() string {
+	fields := make([]string, len(v.Fields))
+	for i, f := range v.Fields {
+		fields[i] = f.String()
+	}
+	return fmt.Sprintf("invalid %s: %s", v.Options, strings.Join(fields, "; "))
+}
+
+/*
+Is allows using errors.Is(err, ErrInvalidOptions).
+To get the invalid fields, use errors.As(*ValidationError).
+*/
+func (v *ValidationError) Is(target error) bool {
+	return target == ErrInvalidOptions
+}
+
+func validateOptions(options any) error {
+	if v, ok := options.(Validator); ok {
+		return v.Validate()
+	}
+	return nil
+}
+
+func (f *fieldErrors) add(field, msg string) {
+	*f = append(*f, FieldError{Field: field, Msg: msg})
+}
+
+func (f *fieldErrors) required(field, v string) {
+	if strings.TrimSpace(v) == "" {
+		f.add(field, "is required")
+	}
+}
+
+// url checks that v is an absolute http(s) URL. Empty values are only
+// reported when required is set.
+func (f *fieldErrors) url(field, v string, required bool) {
+	if v == "" {
+		if required {
+			f.add(field, "is required")
+		}
+		return
+	}
+	u, err := url.Parse(v)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		f.add(field, fmt.Sprintf("%q is not an absolute http(s) URL", v))
+	}
+}
+
+// countryCode checks that v, if set, is an ISO 3166-1 alpha-2 code.
+func (f *fieldErrors) countryCode(field, v string) {
+	if v == "" {
+		return
+	}
+	if len(v) != 2 || !isASCIILetter(v[0]) || !isASCIILetter(v[1]) {
+		f.add(field, fmt.Sprintf("%q is not a two-letter country code", v))
+	}
+}
+
+func (f fieldErrors) err(options any) error {
+	if len(f) == 0 {
+		return nil
+	}
+	return &ValidationError{
+		Options: typeName(options),
+		Fields:  f,
+	}
+}
+
+func isASCIILetter(b byte) bool {
+	return ('a' <= b && b <= 'z') || ('A' <= b && b <= 'Z')
+}
+
+func (o KasadaStandardOptions) Validate() error {
+	var f fieldErrors
+	f.required("pjs", o.Pjs)
+	return f.err(o)
+}
+
+func (o KasadaPayloadOptions) Validate() error {
+	var f fieldErrors
+	f.url("url", o.URL, true)
+	switch {
+	case o.ScriptURL != "" && o.ScriptContent != "":
+		f.add("script_url", "is mutually exclusive with script_content")
+	case o.ScriptURL == "" && o.ScriptContent == "":
+		f.add("script_url", "either script_url or script_content is required")
+	default:
+		f.url("script_url", o.ScriptURL, false)
+	}
+	return f.err(o)
+}
+
+func (o AkamaiWebOptions) Validate() error {
+	var f fieldErrors
+	f.url("url", o.URL, true)
+	f.url("sensor_url", o.SensorUrl, false)
+	if o.Count < 0 {
+		f.add("count", "must not be negative")
+	}
+	return f.err(o)
+}
+
+func (o AkamaiSBSDOptions) Validate() error {
+	var f fieldErrors
+	f.url("url", o.URL, true)
+	f.url("sbsd_url", o.SbsdURL, false)
+	return f.err(o)
+}
+
+func (o Reese84Options) Validate() error {
+	var f fieldErrors
+	f.url("website", o.Website, true)
+	return f.err(o)
+}
+
+func (o UutmvcOptions) Validate() error {
+	var f fieldErrors
+	f.url("website", o.Website, true)
+	return f.err(o)
+}
+
+func (o DataDomeInterstitialOptions) Validate() error {
+	var f fieldErrors
+	f.url("captcha_url", o.CaptchaURL, true)
+	f.countryCode("country_code", o.CountryCode)
+	return f.err(o)
+}
+
+func (o DataDomeSliderOptions) Validate() error {
+	var f fieldErrors
+	f.url("captcha_url", o.CaptchaURL, true)
+	f.countryCode("country_code", o.CountryCode)
+	return f.err(o)
+}
+
+func (o TwitchScraperOptions) Validate() error {
+	return nil
+}
+
+func (o TwitchIntegrityOptions) Validate() error {
+	var f fieldErrors
+	f.required("client_Id", o.ClientID)
+	return f.err(o)
+}
@@ -0,0 +1,88 @@
+package salamoonder
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name       string
+		options    Validator
+		wantFields []string
+	}{
+		{"kasada ok", KasadaStandardOptions{Pjs: "https://example.com/p.js"}, nil},
+		{"kasada empty", KasadaStandardOptions{}, []string{"pjs"}},
+		{"payload ok", KasadaPayloadOptions{URL: "https://example.com", ScriptContent: "x"}, nil},
+		{"payload both scripts", KasadaPayloadOptions{URL: "https://example.com", ScriptURL: "https://example.com/s.js", ScriptContent: "x"}, []string{"script_url"}},
+		{"payload no script", KasadaPayloadOptions{URL: "https://example.com"}, []string{"script_url"}},
+		{"payload bad urls", KasadaPayloadOptions{URL: "example.com", ScriptURL: "::"}, []string{"url", "script_url"}},
+		{"akamai web", AkamaiWebOptions{URL: "https://example.com", SensorUrl: "/relative", Count: -1}, []string{"sensor_url", "count"}},
+		{"akamai sbsd", AkamaiSBSDOptions{}, []string{"url"}},
+		{"reese84", Reese84Options{Website: "ftp://example.com"}, []string{"website"}},
+		{"utmvc", UutmvcOptions{}, []string{"website"}},
+		{"datadome ok", DataDomeSliderOptions{CaptchaURL: "https://geo.captcha-delivery.com/captcha/", CountryCode: "us"}, nil},
+		{"datadome bad country", DataDomeInterstitialOptions{CaptchaURL: "https://geo.captcha-delivery.com/", CountryCode: "USA"}, []string{"country_code"}},
+		{"twitch scraper", TwitchScraperOptions{}, nil},
+		{"twitch integrity", TwitchIntegrityOptions{AccessToken: "acc"}, []string{"client_Id"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.options.Validate()
+			if len(tt.wantFields) == 0 {
+				if err != nil {
+					t.Fatalf("Validate() error: %v", err)
+				}
+				return
+			}
+
+			var valErr *ValidationError
+			if !errors.As(err, &valErr) {
+				t.Fatalf("Validate() error type = %T, want *ValidationError", err)
+			}
+			if len(valErr.Fields) != len(tt.wantFields) {
+				t.Fatalf("Validate() fields = %v, want %v", valErr.Fields, tt.wantFields)
+			}
+			for i, f := range valErr.Fields {
+				if f.Field != tt.wantFields[i] {
+					t.Errorf("Fields[%d].Field = %q, want %q", i, f.Field, tt.wantFields[i])
+				}
+			}
+		})
+	}
+}
+
+func TestCreateTask_ValidationError(t *testing.T) {
+	var calls atomic.Int32
+	h := func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"error_code":0,"error_description":"","taskId":"task-1"}`))
+	}
+
+	c, closeFn := newTestClient(t, h)
+	defer closeFn()
+
+	_, err := c.CreateTask(context.Background(), KasadaPayloadOptions{})
+	if !errors.Is(err, ErrInvalidOptions) {
+		t.Fatalf("errors.Is(err, ErrInvalidOptions) = false, want true; err = %v", err)
+	}
+	if n := calls.Load(); n != 0 {
+		t.Errorf("server calls = %d, want 0", n)
+	}
+
+	c, closeFn2 := newTestClient(t, h, WithoutValidation())
+	defer closeFn2()
+
+	if _, err := c.CreateTask(context.Background(), KasadaPayloadOptions{}); err != nil {
+		t.Fatalf("CreateTask() with WithoutValidation error: %v", err)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("server calls = %d, want 1", n)
+	}
+}